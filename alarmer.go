@@ -14,3 +14,20 @@ var alarmer Alarmer
 func SetAlarmer(a Alarmer) {
 	alarmer = a
 }
+
+// alarmCreated calls the alarmer for a new error whose effective severity
+// level, chosen by SeverityPolicy, is registered with Alarm, unless
+// the error it's derived from or an error it wraps already raised the alarm.
+// So one failure raises it once, however it's wrapped.
+func alarmCreated(e *Error) {
+	if alarmer == nil || e.alarmed || !EffectiveSeverity(e).Info().Alarm {
+		return
+	}
+	e.alarmed = true
+	for x := range chainErrors(e.err) {
+		if x.alarmed {
+			return
+		}
+	}
+	alarmer.Alarm(e)
+}
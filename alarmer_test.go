@@ -15,6 +15,14 @@ func (m *MockAlarmer) Alarm(err error) {
 	m.err = err
 }
 
+type countingAlarmer struct {
+	n int
+}
+
+func (c *countingAlarmer) Alarm(error) {
+	c.n++
+}
+
 func TestSetAlarmer(t *testing.T) {
 	mock := &MockAlarmer{}
 	SetAlarmer(mock)
@@ -0,0 +1,17 @@
+module github.com/axkit/errors/benchmarks
+
+go 1.23.0
+
+require (
+	github.com/axkit/errors v0.0.0
+	github.com/tidwall/sjson v1.2.5
+)
+
+require (
+	github.com/tidwall/gjson v1.14.2 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
+	google.golang.org/protobuf v1.36.8 // indirect
+)
+
+replace github.com/axkit/errors => ../
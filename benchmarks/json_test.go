@@ -0,0 +1,101 @@
+// Package benchmarks compares the JSON encoding of errors with the way
+// ToJSON encoded them before the streaming encoder. It's a separate module,
+// so the errors module doesn't depend on sjson.
+package benchmarks
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/axkit/errors"
+	"github.com/tidwall/sjson"
+)
+
+func benchmarkError() *errors.Error {
+	return errors.Template("customer not found").Code("CRM-0404").Severity(errors.Tiny).StatusCode(404).
+		Wrap(io.EOF).
+		Set("customerId", 42).
+		Set("email", "john@example.com").
+		Set("requestId", "req-1")
+}
+
+var (
+	rootLevelFields  = []string{"requestId"}
+	benchmarkOptions = []errors.Option{
+		errors.WithAttributes(errors.AddWrappedErrors | errors.AddFields),
+		errors.WithRootLevelFields(rootLevelFields),
+	}
+)
+
+// legacyToJSON encodes the error as ToJSON did before the streaming
+// encoder: the serialized error is marshaled by encoding/json and
+// the root-level fields are set into the output by sjson.
+func legacyToJSON(err error, opts ...errors.Option) []byte {
+	serr := errors.Serialize(err, opts...)
+
+	var root map[string]any
+	if len(rootLevelFields) > 0 {
+		root = make(map[string]any)
+		for k, v := range serr.Fields {
+			if slices.Contains(rootLevelFields, k) {
+				root[k] = v
+				delete(serr.Fields, k)
+			}
+		}
+	}
+
+	buf, merr := json.Marshal(serr)
+	if merr != nil {
+		return nil
+	}
+	for k, v := range root {
+		buf, _ = sjson.SetBytes(buf, k, v)
+	}
+	return buf
+}
+
+func TestLegacyToJSON(t *testing.T) {
+	err := benchmarkError()
+
+	var got, expected any
+	if uerr := json.Unmarshal(errors.ToJSON(err, benchmarkOptions...), &got); uerr != nil {
+		t.Fatal(uerr)
+	}
+	if uerr := json.Unmarshal(legacyToJSON(err, benchmarkOptions...), &expected); uerr != nil {
+		t.Fatal(uerr)
+	}
+	gb, _ := json.Marshal(got)
+	eb, _ := json.Marshal(expected)
+	if string(gb) != string(eb) {
+		t.Errorf("expected %s, got %s", eb, gb)
+	}
+}
+
+func BenchmarkToJSON(b *testing.B) {
+	err := benchmarkError()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = errors.ToJSON(err, benchmarkOptions...)
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	err := benchmarkError()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = errors.WriteJSON(io.Discard, err, benchmarkOptions...)
+	}
+}
+
+func BenchmarkLegacyToJSON(b *testing.B) {
+	err := benchmarkError()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = legacyToJSON(err, benchmarkOptions...)
+	}
+}
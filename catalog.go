@@ -0,0 +1,300 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog holds client messages translated to several languages and keyed
+// by error code. It's safe for concurrent use.
+//
+// A message is looked up in the requested locale, then in the locales of its
+// fallback chain, then in the base language ("pt" for "pt-BR"), and finally
+// in the default locale.
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]string
+	fallbacks     map[string][]string
+	onMissing     func(locale, code string)
+}
+
+// NewCatalog returns an empty catalog with the default locale.
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: normalizeLocale(defaultLocale),
+		messages:      make(map[string]map[string]string),
+		fallbacks:     make(map[string][]string),
+	}
+}
+
+// ErrCatalogLoadFailed is returned by LoadCatalog if a message file
+// cannot be read or parsed.
+var ErrCatalogLoadFailed = Template("message catalog loading failed").Severity(Critical)
+
+// LoadCatalog returns a catalog filled with message files matching the pattern.
+// Each file is a JSON object mapping error codes to messages, and its name
+// without extension is the locale:
+//
+//	//go:embed locales/*.json
+//	var locales embed.FS
+//
+//	catalog, err := errors.LoadCatalog(locales, "locales/*.json", "en")
+func LoadCatalog(fsys fs.FS, pattern string, defaultLocale string) (*Catalog, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, ErrCatalogLoadFailed.Wrap(err).Set("pattern", pattern)
+	}
+
+	c := NewCatalog(defaultLocale)
+	for _, name := range names {
+		buf, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, ErrCatalogLoadFailed.Wrap(err).Set("file", name)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(buf, &messages); err != nil {
+			return nil, ErrCatalogLoadFailed.Wrap(err).Set("file", name)
+		}
+
+		locale := strings.TrimSuffix(path.Base(name), path.Ext(name))
+		for code, msg := range messages {
+			c.Add(locale, code, msg)
+		}
+	}
+	return c, nil
+}
+
+// Add adds or replaces the message for the error code in the locale.
+func (c *Catalog) Add(locale, code, message string) *Catalog {
+	locale = normalizeLocale(locale)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	c.messages[locale][code] = message
+	return c
+}
+
+// SetFallback sets the locales to look up, in order, when a message is
+// missing in the locale.
+//
+//	catalog.SetFallback("uk", "ru", "en")
+func (c *Catalog) SetFallback(locale string, fallbacks ...string) *Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	chain := make([]string, len(fallbacks))
+	for i, f := range fallbacks {
+		chain[i] = normalizeLocale(f)
+	}
+	c.fallbacks[normalizeLocale(locale)] = chain
+	return c
+}
+
+// OnMissing sets the function called when the message for the error code
+// is not found in the requested locale and another locale is used instead,
+// or no translation is found at all.
+func (c *Catalog) OnMissing(fn func(locale, code string)) *Catalog {
+	c.mu.Lock()
+	c.onMissing = fn
+	c.mu.Unlock()
+	return c
+}
+
+// Locales returns the locales having at least one message, sorted.
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	res := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		res = append(res, l)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Message returns the message for the error code in the locale,
+// walking the fallback chain if needed. An empty locale means
+// the default locale.
+func (c *Catalog) Message(locale, code string) (string, bool) {
+	if code == "" {
+		return "", false
+	}
+
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		locale = c.defaultLocale
+	}
+
+	c.mu.RLock()
+	var msg, used string
+	var found bool
+	for _, l := range c.lookupChain(locale) {
+		if msg, found = c.messages[l][code]; found {
+			used = l
+			break
+		}
+	}
+	onMissing := c.onMissing
+	c.mu.RUnlock()
+
+	if used != locale && onMissing != nil {
+		onMissing(locale, code)
+	}
+	return msg, found
+}
+
+// lookupChain returns the locales to look up for the locale, without duplicates.
+func (c *Catalog) lookupChain(locale string) []string {
+	chain := make([]string, 0, 4)
+	add := func(l string) {
+		if l != "" && !slices.Contains(chain, l) {
+			chain = append(chain, l)
+		}
+	}
+
+	add(locale)
+	for _, l := range c.fallbacks[locale] {
+		add(l)
+	}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		add(base)
+	}
+	add(c.defaultLocale)
+	return chain
+}
+
+// Negotiate returns the best locale of the catalog for the Accept-Language
+// header value. The default locale is returned if nothing matches.
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if _, ok := c.messages[tag]; ok {
+			return tag
+		}
+		if base, _, ok := strings.Cut(tag, "-"); ok {
+			if _, ok := c.messages[base]; ok {
+				return base
+			}
+		}
+	}
+	return c.defaultLocale
+}
+
+// parseAcceptLanguage returns normalized language tags of the header value
+// ordered by quality. Tags with zero quality and the wildcard are skipped.
+func parseAcceptLanguage(header string) []string {
+	var res []string
+	for _, name := range parseQualityList(header) {
+		if name = normalizeLocale(name); name != "" && name != "*" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// parseQualityList returns the values of an Accept-like header ordered
+// by quality, without parameters. Values with zero quality are skipped.
+func parseQualityList(header string) []string {
+	type value struct {
+		name string
+		q    float64
+	}
+
+	var values []value
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > 0 {
+			values = append(values, value{name, q})
+		}
+	}
+
+	slices.SortStableFunc(values, func(a, b value) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	res := make([]string, len(values))
+	for i := range values {
+		res[i] = values[i].name
+	}
+	return res
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+type localeKey struct{}
+
+// ContextWithLocale returns a copy of ctx carrying the locale.
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored by ContextWithLocale.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// WithLocale makes Serialize and ToJSON replace messages of errors having
+// a code with their translation from the catalog. It's intended for client
+// output; the server log keeps the original messages.
+func WithLocale(c *Catalog, locale string) Option {
+	return func(e *ErrorFormattingOptions) {
+		e.catalog = c
+		e.locale = locale
+	}
+}
+
+// WithContextLocale works like WithLocale taking the locale from the context.
+// The default locale of the catalog is used if the context has no locale.
+func WithContextLocale(ctx context.Context, c *Catalog) Option {
+	locale, _ := LocaleFromContext(ctx)
+	return WithLocale(c, locale)
+}
+
+// localize returns the translation of the message for the error code,
+// or the message itself if the translation is not available.
+func (o *ErrorFormattingOptions) localize(code, message string) string {
+	if o.catalog == nil {
+		return message
+	}
+	if msg, ok := o.catalog.Message(o.locale, code); ok {
+		return msg
+	}
+	return message
+}
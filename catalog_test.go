@@ -0,0 +1,149 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testLocales = fstest.MapFS{
+	"locales/en.json":    {Data: []byte(`{"CRM-0404":"customer not found","CRM-0901":"invalid input"}`)},
+	"locales/de.json":    {Data: []byte(`{"CRM-0404":"Kunde nicht gefunden"}`)},
+	"locales/pt.json":    {Data: []byte(`{"CRM-0404":"cliente não encontrado","CRM-0901":"entrada inválida"}`)},
+	"locales/pt_BR.json": {Data: []byte(`{"CRM-0404":"cliente não localizado"}`)},
+	"locales/uk.json":    {Data: []byte(`{"CRM-0404":"клієнта не знайдено"}`)},
+	"broken/en.json":     {Data: []byte(`{"CRM-0404":`)},
+}
+
+func TestLoadCatalog(t *testing.T) {
+	c, err := LoadCatalog(testLocales, "locales/*.json", "en")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"de", "en", "pt", "pt-br", "uk"}
+	if got := c.Locales(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := LoadCatalog(testLocales, "broken/*.json", "en"); !Is(err, ErrCatalogLoadFailed) {
+		t.Errorf("expected ErrCatalogLoadFailed, got %v", err)
+	}
+
+	if _, err := LoadCatalog(testLocales, "[", "en"); !Is(err, ErrCatalogLoadFailed) {
+		t.Errorf("expected ErrCatalogLoadFailed, got %v", err)
+	}
+}
+
+func TestCatalog_Message(t *testing.T) {
+	c, _ := LoadCatalog(testLocales, "locales/*.json", "en")
+	c.SetFallback("uk", "de")
+
+	var missing []string
+	c.OnMissing(func(locale, code string) {
+		missing = append(missing, locale+"/"+code)
+	})
+
+	tests := []struct {
+		locale   string
+		code     string
+		expected string
+		found    bool
+	}{
+		{"de", "CRM-0404", "Kunde nicht gefunden", true},
+		{"pt-BR", "CRM-0404", "cliente não localizado", true},
+		{"pt_BR", "CRM-0901", "entrada inválida", true},
+		{"uk", "CRM-0901", "invalid input", true},
+		{"DE", "CRM-0901", "invalid input", true},
+		{"", "CRM-0901", "invalid input", true},
+		{"fr", "CRM-0404", "customer not found", true},
+		{"de", "CRM-9999", "", false},
+		{"de", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.code, func(t *testing.T) {
+			msg, found := c.Message(tt.locale, tt.code)
+			if msg != tt.expected || found != tt.found {
+				t.Errorf("expected %q (%v), got %q (%v)", tt.expected, tt.found, msg, found)
+			}
+		})
+	}
+
+	expectedMissing := []string{"pt-br/CRM-0901", "uk/CRM-0901", "de/CRM-0901", "fr/CRM-0404", "de/CRM-9999"}
+	if !reflect.DeepEqual(missing, expectedMissing) {
+		t.Errorf("expected missing %v, got %v", expectedMissing, missing)
+	}
+}
+
+func TestCatalog_Negotiate(t *testing.T) {
+	c, _ := LoadCatalog(testLocales, "locales/*.json", "en")
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"fr-CH, fr;q=0.9, de;q=0.7, *;q=0.5", "de"},
+		{"pt-BR,pt;q=0.8", "pt-br"},
+		{"de-AT", "de"},
+		{"uk;q=0.3, de;q=0.8", "de"},
+		{"de;q=0, uk", "uk"},
+		{"*", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := c.Negotiate(tt.header); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithLocale(t *testing.T) {
+	c, _ := LoadCatalog(testLocales, "locales/*.json", "en")
+
+	ErrCustomerNotFound := Template("customer not found").Code("CRM-0404").StatusCode(404)
+	err := Template("lookup failed").Wrap(ErrCustomerNotFound.New())
+
+	se := Serialize(err, WithAttributes(AddWrappedErrors), WithLocale(c, "de"))
+	if se.Message != "lookup failed" {
+		t.Errorf("expected message without code to be kept, got %q", se.Message)
+	}
+
+	got := make([]string, len(se.Wrapped))
+	for i := range se.Wrapped {
+		got[i] = se.Wrapped[i].Message
+	}
+	expected := []string{"Kunde nicht gefunden"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	ctx := ContextWithLocale(context.Background(), "pt")
+	var res SerializedError
+	_ = json.Unmarshal(ToJSON(ErrCustomerNotFound.New(), WithContextLocale(ctx, c)), &res)
+	if res.Message != "cliente não encontrado" {
+		t.Errorf("expected localized message, got %q", res.Message)
+	}
+
+	_ = json.Unmarshal(ToJSON(ErrCustomerNotFound.New()), &res)
+	if res.Message != "customer not found" {
+		t.Errorf("expected original message, got %q", res.Message)
+	}
+}
+
+func TestLocaleFromContext(t *testing.T) {
+	if _, ok := LocaleFromContext(context.Background()); ok {
+		t.Error("expected no locale")
+	}
+
+	ctx := ContextWithLocale(context.Background(), "de")
+	if locale, ok := LocaleFromContext(ctx); !ok || locale != "de" {
+		t.Errorf("expected %q, got %q", "de", locale)
+	}
+}
@@ -0,0 +1,82 @@
+package errors
+
+import "iter"
+
+// chainLevel holds the attributes of a single error or template in the chain.
+type chainLevel struct {
+	metadata  *metadata
+	fields    map[string]any
+	sensitive map[string]Redactor
+	schema    []fieldSpec
+}
+
+// chainLevels returns an iterator over errors and templates in the chain,
+// starting from the outermost one. Standard errors are unwrapped and skipped;
+// errors wrapping several errors, like those created by fmt.Errorf with
+// multiple %w verbs, are walked depth-first in order.
+func chainLevels(err error) iter.Seq[chainLevel] {
+	return func(yield func(chainLevel) bool) {
+		walkChain(err, yield)
+	}
+}
+
+// walkChain passes chain levels to yield. It returns false if yield stopped the walk.
+func walkChain(err error, yield func(chainLevel) bool) bool {
+	for err != nil {
+		switch x := err.(type) {
+		case *Error:
+			if !yield(chainLevel{&x.metadata, x.fields, x.sensitive, x.schema}) {
+				return false
+			}
+			err = x.err
+		case *ErrorTemplate:
+			return yield(chainLevel{&x.metadata, x.fields, x.sensitive, x.schema})
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				if !walkChain(e, yield) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// chainErrors returns an iterator over the errors in the chain, starting
+// from the outermost one, walked the same way as chainLevels. Templates
+// are skipped.
+func chainErrors(err error) iter.Seq[*Error] {
+	return func(yield func(*Error) bool) {
+		walkErrors(err, yield)
+	}
+}
+
+// walkErrors passes errors to yield. It returns false if yield stopped the walk.
+func walkErrors(err error, yield func(*Error) bool) bool {
+	for err != nil {
+		switch x := err.(type) {
+		case *Error:
+			if !yield(x) {
+				return false
+			}
+			err = x.err
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				if !walkErrors(e, yield) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// ContextExtractor returns a value taken from the context and true
+// if the value is present.
+type ContextExtractor func(ctx context.Context) (any, bool)
+
+type contextExtractor struct {
+	field   string
+	extract ContextExtractor
+}
+
+var (
+	contextExtractorsMu sync.RWMutex
+	contextExtractors   []contextExtractor
+)
+
+// RegisterContextExtractor registers a function that takes a value from
+// the context. The value is attached as the field to errors created by
+// WrapCtx, ErrorTemplate.NewCtx, ErrorTemplate.WrapCtx and Error.WithContext.
+//
+// Registering the same field twice replaces the previous extractor.
+func RegisterContextExtractor(field string, fn ContextExtractor) {
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+
+	for i := range contextExtractors {
+		if contextExtractors[i].field == field {
+			contextExtractors[i].extract = fn
+			return
+		}
+	}
+	contextExtractors = append(contextExtractors, contextExtractor{field: field, extract: fn})
+}
+
+// ContextValue returns a ContextExtractor that takes the value stored
+// in the context under the key.
+//
+//	errors.RegisterContextExtractor("requestId", errors.ContextValue(requestIDKey{}))
+func ContextValue(key any) ContextExtractor {
+	return func(ctx context.Context) (any, bool) {
+		v := ctx.Value(key)
+		return v, v != nil
+	}
+}
+
+// WithContext attaches the values of registered context extractors as fields.
+// Fields already set on the error are not overwritten.
+func (e *Error) WithContext(ctx context.Context) *Error {
+	if ctx == nil {
+		return e
+	}
+
+	// The extractors are called without holding the lock,
+	// so they may register extractors themselves.
+	contextExtractorsMu.RLock()
+	extractors := slices.Clone(contextExtractors)
+	contextExtractorsMu.RUnlock()
+
+	for _, ce := range extractors {
+		if _, ok := e.fields[ce.field]; ok {
+			continue
+		}
+		if v, ok := ce.extract(ctx); ok {
+			e.Set(ce.field, v)
+		}
+	}
+	return e
+}
+
+// WrapCtx works like Wrap and attaches the values of registered context
+// extractors as fields.
+func WrapCtx(ctx context.Context, err error, message string) *Error {
+	return wrap(err, message, 1).WithContext(ctx)
+}
+
+// NewCtx works like New and attaches the values of registered context
+// extractors as fields.
+func (et *ErrorTemplate) NewCtx(ctx context.Context) *Error {
+	return et.new(1).WithContext(ctx)
+}
+
+// WrapCtx works like Wrap and attaches the values of registered context
+// extractors as fields.
+func (et *ErrorTemplate) WrapCtx(ctx context.Context, err error) *Error {
+	return et.wrap(err, 1).WithContext(ctx)
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+type tenantIDKey struct{}
+
+func registerTestExtractors(t *testing.T) {
+	t.Helper()
+
+	RegisterContextExtractor("requestId", ContextValue(requestIDKey{}))
+	RegisterContextExtractor("tenantId", ContextValue(tenantIDKey{}))
+	t.Cleanup(func() {
+		contextExtractorsMu.Lock()
+		contextExtractors = nil
+		contextExtractorsMu.Unlock()
+	})
+}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	registerTestExtractors(t)
+
+	RegisterContextExtractor("requestId", func(ctx context.Context) (any, bool) {
+		return "replaced", true
+	})
+
+	if len(contextExtractors) != 2 {
+		t.Fatalf("expected 2 extractors, got %d", len(contextExtractors))
+	}
+
+	err := Template("failure").NewCtx(context.Background())
+	if v := err.fields["requestId"]; v != "replaced" {
+		t.Errorf("expected replaced extractor to be used, got %v", v)
+	}
+}
+
+func TestContextEnrichment(t *testing.T) {
+	registerTestExtractors(t)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, tenantIDKey{}, 42)
+
+	tmpl := Template("customer not found").Code("CRM-0404")
+
+	tests := []struct {
+		name string
+		err  *Error
+	}{
+		{"WrapCtx", WrapCtx(ctx, io.EOF, "reading failed")},
+		{"ErrorTemplate.NewCtx", tmpl.NewCtx(ctx)},
+		{"ErrorTemplate.WrapCtx", tmpl.WrapCtx(ctx, io.EOF)},
+		{"Error.WithContext", tmpl.New().WithContext(ctx)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v := tt.err.fields["requestId"]; v != "req-1" {
+				t.Errorf("expected requestId %q, got %v", "req-1", v)
+			}
+			if v := tt.err.fields["tenantId"]; v != 42 {
+				t.Errorf("expected tenantId 42, got %v", v)
+			}
+			if len(tt.err.stack) == 0 {
+				t.Error("expected stack trace to be populated")
+			}
+		})
+	}
+}
+
+func TestError_WithContextKeepsFields(t *testing.T) {
+	registerTestExtractors(t)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	err := Template("failure").New().Set("requestId", "explicit").WithContext(ctx)
+
+	if v := err.fields["requestId"]; v != "explicit" {
+		t.Errorf("expected explicit value to be kept, got %v", v)
+	}
+	if _, ok := err.fields["tenantId"]; ok {
+		t.Error("expected missing context value to be skipped")
+	}
+
+	var nilCtx context.Context
+	if err := Template("failure").New().WithContext(nilCtx); len(err.fields) != 0 {
+		t.Errorf("expected no fields for nil context, got %v", err.fields)
+	}
+}
+
+func TestContextStack(t *testing.T) {
+	ctx := context.Background()
+	tmpl := Template("failure")
+
+	tests := []struct {
+		name string
+		err  func() *Error
+	}{
+		{"WrapCtx", func() *Error { return WrapCtx(ctx, io.EOF, "reading failed") }},
+		{"Wrap", func() *Error { return Wrap(io.EOF, "reading failed") }},
+		{"Wrapf", func() *Error { return Wrapf(io.EOF, "reading %s", "failed") }},
+		{"ErrorTemplate.NewCtx", func() *Error { return tmpl.NewCtx(ctx) }},
+		{"ErrorTemplate.New", func() *Error { return tmpl.New() }},
+		{"ErrorTemplate.WrapCtx", func() *Error { return tmpl.WrapCtx(ctx, io.EOF) }},
+		{"ErrorTemplate.Wrap", func() *Error { return tmpl.Wrap(io.EOF) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err()
+			if len(err.stack) == 0 {
+				t.Fatal("expected stack trace to be populated")
+			}
+			if fn := err.stack[0].Function; !strings.HasPrefix(fn, "github.com/axkit/errors.TestContextStack.func") {
+				t.Errorf("expected stack trace to start at the caller, got %s", fn)
+			}
+		})
+	}
+}
+
+func TestError_WithContextReentrant(t *testing.T) {
+	registerTestExtractors(t)
+
+	RegisterContextExtractor("nested", func(ctx context.Context) (any, bool) {
+		RegisterContextExtractor("late", ContextValue(tenantIDKey{}))
+		return "ok", true
+	})
+
+	err := Template("failure").NewCtx(context.Background())
+	if v := err.fields["nested"]; v != "ok" {
+		t.Errorf("expected nested extractor value, got %v", v)
+	}
+}
@@ -0,0 +1,57 @@
+package errors
+
+// ChainPolicy defines how an attribute is chosen when several errors
+// in the wrapped chain have it set.
+type ChainPolicy uint8
+
+const (
+	// PolicyOutermost takes the value of the outermost error, even if it's zero.
+	PolicyOutermost ChainPolicy = iota
+
+	// PolicyMaxInChain takes the greatest value found in the chain.
+	PolicyMaxInChain
+
+	// PolicyInnermostNonZero takes the value of the innermost error
+	// having it set.
+	PolicyInnermostNonZero
+)
+
+var (
+	// SeverityPolicy is used by EffectiveSeverity.
+	SeverityPolicy = PolicyOutermost
+
+	// StatusCodePolicy is used by EffectiveStatusCode.
+	StatusCodePolicy = PolicyOutermost
+)
+
+// EffectiveSeverity returns the severity level of the error chain chosen
+// according to SeverityPolicy. It's used by Serialize, ToJSON and
+// automatic alarming.
+func EffectiveSeverity(err error) SeverityLevel {
+	return effective(err, SeverityPolicy, func(md *metadata) SeverityLevel { return md.severity })
+}
+
+// EffectiveStatusCode returns the HTTP status code of the error chain chosen
+// according to StatusCodePolicy. It's used by Serialize and ToJSON.
+func EffectiveStatusCode(err error) int {
+	return effective(err, StatusCodePolicy, func(md *metadata) int { return md.statusCode })
+}
+
+func effective[T SeverityLevel | int](err error, policy ChainPolicy, value func(*metadata) T) T {
+	var res T
+
+	for l := range chainLevels(err) {
+		v := value(l.metadata)
+		switch policy {
+		case PolicyOutermost:
+			return v
+		case PolicyMaxInChain:
+			res = max(res, v)
+		case PolicyInnermostNonZero:
+			if v != 0 {
+				res = v
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func setPolicies(t *testing.T, severity, statusCode ChainPolicy) {
+	t.Helper()
+	SeverityPolicy, StatusCodePolicy = severity, statusCode
+	t.Cleanup(func() {
+		SeverityPolicy, StatusCodePolicy = PolicyOutermost, PolicyOutermost
+	})
+}
+
+func TestEffectiveSeverity(t *testing.T) {
+	inner := Template("db failure").Severity(Critical).StatusCode(503).Wrap(io.EOF)
+	middle := Template("repository failure").Wrap(inner)
+	outer := fmt.Errorf("handler: %w", Template("customer not found").Severity(Tiny).StatusCode(404).Wrap(middle))
+
+	tests := []struct {
+		name       string
+		policy     ChainPolicy
+		severity   SeverityLevel
+		statusCode int
+	}{
+		{"outermost", PolicyOutermost, Tiny, 404},
+		{"max in chain", PolicyMaxInChain, Critical, 503},
+		{"innermost non-zero", PolicyInnermostNonZero, Critical, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPolicies(t, tt.policy, tt.policy)
+
+			if got := EffectiveSeverity(outer); got != tt.severity {
+				t.Errorf("expected severity %v, got %v", tt.severity, got)
+			}
+			if got := EffectiveStatusCode(outer); got != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, got)
+			}
+		})
+	}
+
+	t.Run("outermost zero", func(t *testing.T) {
+		if got := EffectiveSeverity(middle); got != Unknown {
+			t.Errorf("expected %v, got %v", Unknown, got)
+		}
+	})
+
+	t.Run("standard error", func(t *testing.T) {
+		setPolicies(t, PolicyMaxInChain, PolicyMaxInChain)
+		if got := EffectiveSeverity(io.EOF); got != Unknown {
+			t.Errorf("expected %v, got %v", Unknown, got)
+		}
+		if got := EffectiveStatusCode(nil); got != 0 {
+			t.Errorf("expected 0, got %d", got)
+		}
+	})
+}
+
+func TestEffectiveSeverity_Serialize(t *testing.T) {
+	setPolicies(t, PolicyMaxInChain, PolicyInnermostNonZero)
+
+	inner := Template("db failure").Severity(Critical).StatusCode(503).New()
+	outer := Template("customer not found").Severity(Tiny).StatusCode(404).Wrap(inner)
+
+	se := Serialize(outer, WithAttributes(AddWrappedErrors))
+	if se.Severity != scritical || se.StatusCode != 503 {
+		t.Errorf("expected critical/503, got %s/%d", se.Severity, se.StatusCode)
+	}
+	if outer.severity != Tiny || outer.statusCode != 404 {
+		t.Errorf("expected error attributes to stay untouched, got %v/%d", outer.severity, outer.statusCode)
+	}
+}
+
+func TestEffectiveSeverity_Alarm(t *testing.T) {
+	restoreSeverities(t)
+	setPolicies(t, PolicyMaxInChain, PolicyOutermost)
+
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	const Fatal SeverityLevel = 50
+	RegisterSeverity(Fatal, SeverityInfo{Name: "fatal", Alarm: true})
+
+	inner := Template("disk is gone").Severity(Fatal).New()
+	mock.called, mock.err = false, nil
+
+	err := Template("upload failed").Severity(Tiny).Wrap(inner)
+	if mock.called {
+		t.Errorf("expected the alarm to be raised once, when the fatal error is created, got %v", err)
+	}
+	if EffectiveSeverity(err) != Fatal {
+		t.Errorf("expected fatal effective severity, got %v", EffectiveSeverity(err))
+	}
+
+	ErrFatal := Template("disk is gone").Severity(Fatal)
+	tests := []struct {
+		name   string
+		policy ChainPolicy
+		create func() *Error
+		alarm  bool
+	}{
+		{"outermost fatal", PolicyOutermost, func() *Error { return ErrFatal.Wrap(Template("x").Severity(Tiny).New()) }, true},
+		{"outermost tiny", PolicyOutermost, func() *Error { return Template("x").Severity(Tiny).Wrap(ErrFatal) }, false},
+		{"max in chain", PolicyMaxInChain, func() *Error { return Template("x").Severity(Tiny).Wrap(ErrFatal) }, true},
+		{"innermost non-zero", PolicyInnermostNonZero, func() *Error { return ErrFatal.Wrap(Template("x").Severity(Tiny).New()) }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SeverityPolicy = tt.policy
+			mock.called, mock.err = false, nil
+			if err := tt.create(); mock.called != tt.alarm || tt.alarm && mock.err != err {
+				t.Errorf("expected alarm %v, got %v", tt.alarm, mock.err)
+			}
+		})
+	}
+}
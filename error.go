@@ -1,21 +1,40 @@
 package errors
 
+import "fmt"
+
 // Error represents a structured error with metadata, custom fields, stack trace, and optional wrapping.
 type Error struct {
 	metadata
-	fields map[string]any
-	stack  []StackFrame
+	fields    map[string]any
+	sensitive map[string]Redactor
+	schema    []fieldSpec
+	stack     []StackFrame
 
 	pureWrapper bool
 	err         error
+
+	// formatted is true if the message already includes the messages
+	// of wrapped errors, as produced by Errorf.
+	formatted bool
+
+	// uncounted is 1 if the error is created but not yet recorded
+	// as MetricErrorCreated; see markCreated.
+	uncounted uint32
+
+	// alarmed is true if the alarm was raised for the error or an error
+	// it's derived from or wraps; see alarmCreated.
+	alarmed bool
 }
 
 // Error returns the error message, including any wrapped error messages.
+// Placeholders in the message are replaced with field values.
 func (e *Error) Error() string {
+	// Wrapped errors are counted when wrapped, so only e may be uncounted.
+	countCreated(e)
 
-	res := e.message
+	res := formatMessage(e, e.message, &ErrorFormattingOptions{})
 
-	if e.err != nil {
+	if e.err != nil && !e.formatted {
 		prev := e.err.Error()
 		if prev != "" {
 			if res != "" {
@@ -68,27 +87,30 @@ func (e *Error) Wrap(err error) *Error {
 		return e
 	}
 
+	var res *Error
+
 	switch x := err.(type) {
 	case *ErrorTemplate:
-		return &Error{
+		res = &Error{
 			metadata:    e.metadata,
+			alarmed:     e.alarmed,
 			fields:      cloneMap(e.fields),
+			sensitive:   e.sensitive,
+			schema:      e.schema,
 			pureWrapper: true,
 			err:         err,
-			stack:       DefaultCallerFrames(3),
 		}
 	case *Error:
-		res := Error{
+		res = &Error{
 			metadata:    e.metadata,
+			alarmed:     e.alarmed,
 			fields:      cloneMap(e.fields),
+			sensitive:   e.sensitive,
+			schema:      e.schema,
 			pureWrapper: true,
 			err:         err,
 		}
-		if x.stack != nil {
-			res.stack = x.stack
-		} else {
-			res.stack = DefaultCallerFrames(3)
-		}
+		res.stack = x.stack
 		if len(x.fields) > 0 {
 			if res.fields == nil {
 				res.fields = make(map[string]any, len(x.fields))
@@ -97,16 +119,26 @@ func (e *Error) Wrap(err error) *Error {
 				res.fields[k] = v
 			}
 		}
-		return &res
+	default:
+		res = &Error{
+			metadata:    e.metadata,
+			alarmed:     e.alarmed,
+			err:         err,
+			fields:      cloneMap(e.fields),
+			sensitive:   e.sensitive,
+			schema:      e.schema,
+			pureWrapper: true,
+		}
 	}
 
-	return &Error{
-		metadata:    e.metadata,
-		err:         err,
-		fields:      cloneMap(e.fields),
-		pureWrapper: true,
-		stack:       DefaultCallerFrames(3),
+	if res.stack == nil && e.severity.Info().CaptureStack {
+		res.stack = DefaultCallerFrames(3)
 	}
+
+	alarmCreated(res)
+	markCreated(res, true)
+	notify(EventWrap, res)
+	return res
 }
 
 // Set adds or updates a custom key-value pair in the error's fields.
@@ -143,12 +175,34 @@ func (e *Error) Protected(protected bool) *Error {
 }
 
 // Msg sets the error message and marks the error as not being a pure wrapper.
+// The message is used verbatim: unlike template messages, it may not
+// contain placeholders.
 func (e *Error) Msg(s string) *Error {
-	e.message = s
+	e.message = braceEscaper.Replace(s)
 	e.pureWrapper = false
+	e.formatted = false
 	return e
 }
 
+// Msgf formats according to a format specifier and sets the result as the error message.
+// Like Errorf, braces in the formatted message are escaped, so arguments
+// can't introduce placeholders.
+func (e *Error) Msgf(format string, args ...any) *Error {
+	return e.Msg(fmt.Sprintf(format, args...))
+}
+
+// Unwrap returns the wrapped error, if any.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether the error matches the target the same way as
+// the package-level Is does. It allows the standard errors.Is to find
+// errors and templates of this package wrapped by standard errors.
+func (e *Error) Is(target error) bool {
+	return is(e, target)
+}
+
 // Alarm triggers an alert for the error if an alarmer is configured.
 func (e *Error) Alarm() {
 	if alarmer != nil {
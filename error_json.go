@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"encoding/json"
+	se "errors"
+)
+
+// JSONMarshalRule is the serialization rule used by MarshalJSON.
+// By default errors embedded in structs are marshaled as for clients:
+// the message, severity, code, status code and fields only.
+var JSONMarshalRule ErrorSerializationRule = ClientOutputFormat
+
+// MarshalJSON implements json.Marshaler. The error is encoded as ToJSON does
+// with the JSONMarshalRule attributes, so an *Error can be used as a field
+// of response and message structs.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return ToJSON(e, WithAttributes(JSONMarshalRule&^IndentJSON)), nil
+}
+
+// jsonError mirrors SerializedError for decoding.
+type jsonError struct {
+	Message    string         `json:"msg"`
+	Severity   SeverityLevel  `json:"severity"`
+	Code       string         `json:"code"`
+	StatusCode int            `json:"statusCode"`
+	Fields     map[string]any `json:"fields"`
+	Wrapped    []jsonError    `json:"wrapped"`
+	Stack      []StackFrame   `json:"stack"`
+}
+
+func (je *jsonError) toError() *Error {
+	return &Error{
+		metadata: metadata{
+			// The message is already formatted, so braces are escaped
+			// to keep them away from placeholder substitution.
+			message:    braceEscaper.Replace(je.Message),
+			severity:   je.Severity,
+			statusCode: je.StatusCode,
+			code:       je.Code,
+		},
+		fields: je.Fields,
+		stack:  je.Stack,
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It restores an error encoded
+// by MarshalJSON or ToJSON, including the wrapped chain if it was encoded
+// with AddWrappedErrors. Field values are decoded as by encoding/json
+// into map[string]any; numbers become float64.
+//
+// Restored errors match their templates with Is, unless the template
+// message has placeholders or the template is protected.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var je jsonError
+	if err := json.Unmarshal(data, &je); err != nil {
+		return ErrUnmarshalError.Wrap(err)
+	}
+
+	res := je.toError()
+
+	// The wrapped list starts with the error itself,
+	// unless it's a pure wrapper.
+	wrapped := je.Wrapped
+	if len(wrapped) > 0 && sameJSONMetadata(&wrapped[0], &je) {
+		wrapped = wrapped[1:]
+	} else if len(wrapped) > 0 {
+		res.pureWrapper = true
+	}
+
+	var inner error
+	for i := len(wrapped) - 1; i >= 0; i-- {
+		w := &wrapped[i]
+		if inner == nil && w.Code == "" && w.StatusCode == 0 && w.Severity == Unknown {
+			inner = se.New(w.Message)
+			continue
+		}
+		x := w.toError()
+		x.err = inner
+		x.stack = res.stack
+		inner = x
+	}
+	res.err = inner
+	res.formatted = false
+
+	*e = *res
+	return nil
+}
+
+func sameJSONMetadata(a, b *jsonError) bool {
+	return a.Message == b.Message && a.Code == b.Code &&
+		a.Severity == b.Severity && a.StatusCode == b.StatusCode
+}
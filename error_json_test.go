@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestError_MarshalJSON(t *testing.T) {
+	type response struct {
+		OK    bool   `json:"ok"`
+		Error *Error `json:"error,omitempty"`
+	}
+
+	err := Template("customer not found").Code("CRM-0404").Severity(Tiny).StatusCode(404).
+		Wrap(io.EOF).
+		Set("customerId", 42)
+
+	tests := []struct {
+		name     string
+		rule     ErrorSerializationRule
+		resp     response
+		expected string
+	}{
+		{
+			name:     "default rule",
+			rule:     ClientOutputFormat,
+			resp:     response{Error: err},
+			expected: `{"ok":false,"error":{"msg":"customer not found","severity":"tiny","code":"CRM-0404","statusCode":404,"fields":{"customerId":42}}}`,
+		},
+		{
+			name:     "wrapped errors",
+			rule:     AddWrappedErrors | IndentJSON,
+			resp:     response{Error: err},
+			expected: `{"ok":false,"error":{"msg":"customer not found","severity":"tiny","code":"CRM-0404","statusCode":404,"fields":{"customerId":42},"wrapped":[{"msg":"EOF","severity":"unknown"}]}}`,
+		},
+		{
+			name:     "nil error",
+			resp:     response{OK: true},
+			expected: `{"ok":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			JSONMarshalRule = tt.rule
+			t.Cleanup(func() { JSONMarshalRule = ClientOutputFormat })
+
+			buf, merr := json.Marshal(tt.resp)
+			if merr != nil {
+				t.Fatalf("unexpected error: %v", merr)
+			}
+			if string(buf) != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf)
+			}
+		})
+	}
+
+	var nilErr *Error
+	if buf, _ := nilErr.MarshalJSON(); string(buf) != "null" {
+		t.Errorf("expected null, got %s", buf)
+	}
+}
+
+func TestError_UnmarshalJSON(t *testing.T) {
+	errNotFound := Template("customer not found").Code("CRM-0404").Severity(Tiny).StatusCode(404)
+	errLookup := Template("lookup failed").Code("CRM-0500").Severity(Medium)
+
+	tests := []struct {
+		name      string
+		err       *Error
+		rule      ErrorSerializationRule
+		message   string
+		templates []*ErrorTemplate
+		wrapsEOF  bool
+	}{
+		{
+			name:      "single error",
+			err:       errNotFound.New().Set("customerId", 42),
+			message:   "customer not found",
+			templates: []*ErrorTemplate{errNotFound},
+		},
+		{
+			name:      "wrapped chain",
+			err:       errLookup.Wrap(errNotFound.Wrap(io.EOF)),
+			rule:      AddWrappedErrors | AddStack,
+			message:   "lookup failed: customer not found: EOF",
+			templates: []*ErrorTemplate{errLookup, errNotFound},
+			wrapsEOF:  true,
+		},
+		{
+			name:      "own message",
+			err:       Wrap(errNotFound.New(), "reading {customer}"),
+			rule:      AddWrappedErrors,
+			message:   "reading {customer}",
+			templates: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := ToJSON(tt.err, WithAttributes(tt.rule))
+
+			var got Error
+			if uerr := json.Unmarshal(buf, &got); uerr != nil {
+				t.Fatalf("unexpected error: %v", uerr)
+			}
+
+			if got.Error() != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, got.Error())
+			}
+			for _, et := range tt.templates {
+				if !Is(&got, et) {
+					t.Errorf("expected restored error to match %q", et.message)
+				}
+			}
+			if tt.wrapsEOF && got.Unwrap() == nil {
+				t.Errorf("expected wrapped chain to be restored")
+			}
+			if tt.rule&AddStack != 0 && !reflect.DeepEqual(got.stack, tt.err.stack) {
+				t.Errorf("expected stack to be restored")
+			}
+			if again := ToJSON(&got, WithAttributes(tt.rule)); string(again) != string(buf) {
+				t.Errorf("expected round trip:\n%s\ngot:\n%s", buf, again)
+			}
+		})
+	}
+}
+
+func TestError_UnmarshalJSONFields(t *testing.T) {
+	var got struct {
+		Error *Error `json:"error"`
+	}
+
+	data := `{"error":{"msg":"failure","severity":"critical","code":"E1","fields":{"n":1,"s":"x"}}}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Error.severity != Critical || got.Error.code != "E1" {
+		t.Errorf("unexpected metadata %+v", got.Error.metadata)
+	}
+	if v, ok := Get(got.Error, Key[int]("n")); !ok || v != 1 {
+		t.Errorf("expected field n=1, got %v (found: %v)", v, ok)
+	}
+
+	var e Error
+	if err := e.UnmarshalJSON([]byte(`{"msg":`)); !Is(err, ErrUnmarshalError) {
+		t.Errorf("expected ErrUnmarshalError, got %v", err)
+	}
+	if err := e.UnmarshalJSON([]byte(`null`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
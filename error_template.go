@@ -1,5 +1,10 @@
 package errors
 
+import (
+	"maps"
+	"slices"
+)
+
 // ErrorTemplate defines a reusable error blueprint that includes metadata
 // and custom key-value fields. It is designed for creating structured errors
 // with consistent attributes such as severity and HTTP status code.
@@ -9,58 +14,82 @@ type ErrorTemplate struct {
 
 	// fields holds the error's custom key-value pairs.
 	fields map[string]any
+
+	// sensitive holds the redactors of the fields marked as sensitive.
+	sensitive map[string]Redactor
+
+	// schema holds the declared fields.
+	schema []fieldSpec
 }
 
 // Template returns a new ErrorTemplate initialized with the given message.
 // It can be extended with additional attributes and reused to create
 // multiple error instances.
+//
+// The message may contain placeholders like "customer {customerId} not found",
+// which are replaced with the values of the fields of the same name when
+// the error message is produced. Use "{{" and "}}" for literal braces.
 func Template(msg string) *ErrorTemplate {
-	return &ErrorTemplate{
+	res := &ErrorTemplate{
 		metadata: metadata{
 			message: msg,
 		},
 	}
+	notify(EventTemplate, res)
+	return res
 }
 
 // Error returns the error message from the template.
+// Placeholders in the message are replaced with the template's field values.
 func (et *ErrorTemplate) Error() string {
-	return et.message
+	return formatMessage(et, et.message, &ErrorFormattingOptions{})
 }
 
 // toError converts the ErrorTemplate to an Error instance.
 func (et *ErrorTemplate) toError() *Error {
 	return &Error{
-		metadata: et.metadata,
-		fields:   cloneMap(et.fields),
+		metadata:  et.metadata,
+		fields:    cloneMap(et.fields),
+		sensitive: maps.Clone(et.sensitive),
+		schema:    slices.Clone(et.schema),
 	}
 }
 
 // Wrap wraps an existing error with the ErrorTemplate's metadata and fields.
 // It supports wrapping both ErrorTemplate and Error types,
-// preserving their fields and stack trace.
+// preserving their fields and stack trace. If the wrapped error has no
+// stack trace, a new one is captured, unless the template's severity level
+// is registered without CaptureStack.
 func (et *ErrorTemplate) Wrap(err error) *Error {
+	return et.wrap(err, 1)
+}
+
+// wrap implements Wrap. The captured stack trace omits wrap
+// and skip more frames above it.
+func (et *ErrorTemplate) wrap(err error, skip int) *Error {
+
+	var res *Error
 
 	switch x := err.(type) {
 	case *ErrorTemplate:
-		return &Error{
+		res = &Error{
 			metadata:    et.metadata,
 			fields:      cloneMap(et.fields),
+			sensitive:   maps.Clone(et.sensitive),
+			schema:      slices.Clone(et.schema),
 			pureWrapper: true,
 			err:         err,
-			stack:       DefaultCallerFrames(3),
 		}
 	case *Error:
-		res := Error{
+		res = &Error{
 			metadata:    et.metadata,
 			fields:      cloneMap(et.fields),
+			sensitive:   maps.Clone(et.sensitive),
+			schema:      slices.Clone(et.schema),
 			pureWrapper: true,
 			err:         err,
 		}
-		if x.stack != nil {
-			res.stack = x.stack
-		} else {
-			res.stack = DefaultCallerFrames(3)
-		}
+		res.stack = x.stack
 		if len(x.fields) > 0 {
 			if res.fields == nil {
 				res.fields = make(map[string]interface{}, len(x.fields))
@@ -69,26 +98,50 @@ func (et *ErrorTemplate) Wrap(err error) *Error {
 				res.fields[k] = v
 			}
 		}
-		return &res
+	default:
+		res = &Error{
+			metadata:    et.metadata,
+			pureWrapper: true,
+			err:         err,
+			fields:      cloneMap(et.fields),
+			sensitive:   maps.Clone(et.sensitive),
+			schema:      slices.Clone(et.schema),
+		}
 	}
 
-	return &Error{
-		metadata:    et.metadata,
-		pureWrapper: true,
-		err:         err,
-		fields:      cloneMap(et.fields),
-		stack:       DefaultCallerFrames(3),
+	if res.stack == nil && et.severity.Info().CaptureStack {
+		res.stack = DefaultCallerFrames(skip + 3)
 	}
+
+	alarmCreated(res)
+	markCreated(res, true)
+	notify(EventWrap, res)
+	return res
 }
 
 // New creates a new Error instance using the template's metadata and fields.
-// A new stack trace is captured at the point of the call.
+// A new stack trace is captured at the point of the call, unless
+// the template's severity level is registered without CaptureStack.
 func (et *ErrorTemplate) New() *Error {
-	return &Error{
-		metadata: et.metadata,
-		fields:   cloneMap(et.fields),
-		stack:    CallerFramesFunc(1),
+	return et.new(1)
+}
+
+// new implements New. The captured stack trace omits new
+// and skip more frames above it.
+func (et *ErrorTemplate) new(skip int) *Error {
+	res := &Error{
+		metadata:  et.metadata,
+		fields:    cloneMap(et.fields),
+		sensitive: maps.Clone(et.sensitive),
+		schema:    slices.Clone(et.schema),
+	}
+	if et.severity.Info().CaptureStack {
+		res.stack = CallerFramesFunc(skip + 3)
 	}
+	alarmCreated(res)
+	markCreated(res, true)
+	notify(EventNew, res)
+	return res
 }
 
 // Set adds a custom key-value pair to the template's fields.
@@ -0,0 +1,122 @@
+package errors
+
+import (
+	se "errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestError_Msgf(t *testing.T) {
+	err := Template("invalid input").New().Msgf("invalid age %d", 17)
+	if err.Error() != "invalid age 17" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	err = Template("invalid input").New().Msgf("bad input %q", "{token}").Set("token", "s3cr3t")
+	if expected := `bad input "{token}"`; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestWrapf(t *testing.T) {
+	err := Wrapf(io.EOF, "reading %s failed", "config.yaml")
+	if err.Error() != "reading config.yaml failed: EOF" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if !Is(err, io.EOF) {
+		t.Error("expected error to wrap io.EOF")
+	}
+	if len(err.stack) == 0 {
+		t.Error("expected stack trace to be populated")
+	}
+
+	err = Wrapf(io.EOF, "bad input %q", "{token}").Set("token", "s3cr3t")
+	if expected := `bad input "{token}": EOF`; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+	if msg := Serialize(err, WithAttributes(AddSensitive)).Message; msg != `bad input "{token}"` {
+		t.Errorf("expected formatted argument to be kept, got %q", msg)
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	ErrCustomerNotFound := Template("customer not found").Code("CRM-0404").StatusCode(404)
+	ErrOrderNotFound := Template("order not found").Code("ORD-0404")
+
+	inner := ErrCustomerNotFound.New().Set("customerId", 42)
+
+	t.Run("no wrapping", func(t *testing.T) {
+		err := Errorf("invalid {json} %q", "body")
+		if err.Error() != `invalid {json} "body"` {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+		if err.err != nil || len(err.stack) == 0 {
+			t.Errorf("expected plain error with stack, got %#v", err)
+		}
+	})
+
+	t.Run("single %w", func(t *testing.T) {
+		err := Errorf("loading %s: %w", "customer", inner)
+		if err.Error() != "loading customer: customer not found" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+		if !Is(err, ErrCustomerNotFound) || !se.Is(err, ErrCustomerNotFound) {
+			t.Error("expected error to match the template")
+		}
+		if err.code != "CRM-0404" || err.statusCode != 404 {
+			t.Errorf("expected metadata to be inherited, got %+v", err.metadata)
+		}
+		if v, ok := Field(err, "customerId"); !ok || v != 42 {
+			t.Errorf("expected field to be inherited, got %v", v)
+		}
+		if len(err.stack) == 0 || &err.stack[0] != &inner.stack[0] {
+			t.Error("expected stack trace of the wrapped error to be kept")
+		}
+		if se := Serialize(err); se.Message != "loading customer: customer not found" {
+			t.Errorf("unexpected serialized message %q", se.Message)
+		}
+	})
+
+	t.Run("multiple %w", func(t *testing.T) {
+		err := Errorf("sync failed: %w; %w", inner, ErrOrderNotFound.Wrap(os.ErrNotExist).Set("orderId", "A-1"))
+		if err.Error() != "sync failed: customer not found; order not found: file does not exist" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+		for _, target := range []error{ErrCustomerNotFound, ErrOrderNotFound, os.ErrNotExist} {
+			if !Is(err, target) {
+				t.Errorf("expected error to match %v", target)
+			}
+		}
+		if v, ok := Field(err, "orderId"); !ok || v != "A-1" {
+			t.Errorf("expected field of the second wrapped error, got %v", v)
+		}
+		if len(err.stack) == 0 {
+			t.Error("expected stack trace to be populated")
+		}
+	})
+
+	t.Run("rewrapped", func(t *testing.T) {
+		err := Template("handler").Wrap(Errorf("loading: %w", io.EOF))
+		if err.Error() != "handler: loading: EOF" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+
+	t.Run("msg resets formatting", func(t *testing.T) {
+		err := Errorf("loading: %w", io.EOF).Msg("reading")
+		if err.Error() != "reading: EOF" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+}
+
+func TestError_Unwrap(t *testing.T) {
+	err := Wrap(io.EOF, "reading failed")
+	if err.Unwrap() != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err.Unwrap())
+	}
+	if !se.Is(err, io.EOF) {
+		t.Error("expected standard errors.Is to find io.EOF")
+	}
+}
@@ -18,7 +18,11 @@ package errors
 
 import (
 	se "errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"sync/atomic"
 )
 
 // metadata holds the metadata for an error, including its message, severity level, etc.
@@ -46,11 +50,13 @@ func (a *metadata) empty() bool {
 		!a.protected
 }
 
+// equal reports whether the metadata are the same. Messages are compared
+// as given, so a verbatim message matches a template with the same text.
 func (a *metadata) equal(b metadata) bool {
-	return a.message == b.message &&
-		a.severity == b.severity &&
+	return a.severity == b.severity &&
 		a.statusCode == b.statusCode && a.code == b.code &&
-		a.protected == b.protected
+		a.protected == b.protected &&
+		(a.message == b.message || rawMessage(a.message) == rawMessage(b.message))
 }
 
 // New creates and returns a standard Go error using the built-in errors.New function.
@@ -64,19 +70,36 @@ func New(msg string) error {
 }
 
 // Wrap wraps an existing error with a new message, effectively creating
-// a new error that includes the previous error.
+// a new error that includes the previous error. The message is used verbatim:
+// unlike template messages, it may not contain placeholders.
+//
+// If err is an Error, the result is its copy with the new message and
+// the alarm isn't raised again. A stack trace is captured if err has none,
+// unless the severity level is registered without CaptureStack.
 func Wrap(err error, message string) *Error {
+	return wrap(err, message, 1)
+}
+
+// wrap implements Wrap. The captured stack trace omits wrap
+// and skip more frames above it.
+func wrap(err error, message string, skip int) *Error {
 	var res Error
+	created := true
 
 	if err != nil {
 		res.err = err
 		switch x := err.(type) {
 		case *Error:
 			res = *x
+			created = false
 			x.pureWrapper = false
+			// The copy replaces x, so it takes over whether x is counted.
+			res.uncounted = atomic.SwapUint32(&x.uncounted, 0)
 		case *ErrorTemplate:
 			res.metadata = x.metadata
 			res.fields = cloneMap(x.fields)
+			res.sensitive = maps.Clone(x.sensitive)
+			res.schema = slices.Clone(x.schema)
 		case error:
 			break
 		default:
@@ -84,11 +107,76 @@ func Wrap(err error, message string) *Error {
 		}
 	}
 
-	res.message = message
-	if len(res.stack) == 0 {
-		res.stack = CallerFramesFunc(1)
+	// Placeholders are interpolated only in template messages,
+	// so braces are escaped to render the message verbatim.
+	res.message = braceEscaper.Replace(message)
+	res.formatted = false
+	if len(res.stack) == 0 && res.severity.Info().CaptureStack {
+		res.stack = CallerFramesFunc(skip + 3)
 	}
 
+	if created {
+		alarmCreated(&res)
+	}
+	markCreated(&res, created)
+	notify(EventWrap, &res)
+	return &res
+}
+
+// Wrapf works like Wrap formatting the message according to a format specifier.
+// Like Errorf, braces in the formatted message are escaped, so arguments
+// can't introduce placeholders.
+func Wrapf(err error, format string, args ...any) *Error {
+	return wrap(err, fmt.Sprintf(format, args...), 1)
+}
+
+// Errorf formats according to a format specifier and returns the result
+// as an Error. Like fmt.Errorf, it supports the %w verb, including multiple
+// %w verbs; wrapped errors remain reachable by Is, As, Field and Get.
+//
+// If exactly one error is wrapped, the new error inherits its metadata,
+// fields and stack trace the same way Wrap does. Otherwise a new stack trace
+// is captured.
+func Errorf(format string, args ...any) *Error {
+	fe := fmt.Errorf(format, args...)
+
+	var res Error
+	created := true
+
+	switch x := fe.(type) {
+	case interface{ Unwrap() error }:
+		res.err = x.Unwrap()
+		switch w := res.err.(type) {
+		case *Error:
+			created = false
+			res.metadata = w.metadata
+			res.fields = cloneMap(w.fields)
+			res.sensitive = w.sensitive
+			res.schema = w.schema
+			res.stack = w.stack
+		case *ErrorTemplate:
+			res.metadata = w.metadata
+			res.fields = cloneMap(w.fields)
+			res.sensitive = maps.Clone(w.sensitive)
+			res.schema = slices.Clone(w.schema)
+		}
+	case interface{ Unwrap() []error }:
+		res.err = fe
+	}
+
+	// The message is already formatted, so braces are escaped to keep
+	// them away from placeholder substitution.
+	res.message = braceEscaper.Replace(fe.Error())
+	res.formatted = res.err != nil
+	if len(res.stack) == 0 && res.severity.Info().CaptureStack {
+		res.stack = CallerFramesFunc(3)
+	}
+
+	if created {
+		alarmCreated(&res)
+	}
+	markCreated(&res, created)
+	notify(EventWrap, &res)
 	return &res
 }
 
@@ -145,6 +233,7 @@ func is(e *Error, target error) bool {
 }
 
 // As checks if the error can be cast to a target type.
+// A nil *Error target matches the first *Error in the chain.
 func As(err error, target any) bool {
 	if err == nil {
 		return false
@@ -174,7 +263,7 @@ func as(e *Error, target any) bool {
 
 	switch t := target.(type) {
 	case **Error:
-		if e.metadata.equal((*t).metadata) || e == *t {
+		if *t == nil || e == *t || e.metadata.equal((*t).metadata) {
 			*t = e
 			return true
 		}
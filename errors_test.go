@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"testing"
 )
@@ -87,6 +88,12 @@ func TestIs(t *testing.T) {
 			target:   err,
 			expected: true,
 		},
+		{
+			name:     "Wrapped with the template message having braces",
+			err:      Wrap(io.EOF, "json {bad}"),
+			target:   Template("json {bad}"),
+			expected: true,
+		},
 		{
 			name:     "Different error",
 			err:      Template("different error"),
@@ -176,6 +183,17 @@ func TestAs(t *testing.T) {
 			t.Errorf("expected field to be %q, got %q", "value", target.fields["key"])
 		}
 	})
+	t.Run("nil Error target", func(t *testing.T) {
+		err := Template("test error").New()
+		var target *Error
+		if !As(fmt.Errorf("handler: %w", err), &target) || target != err {
+			t.Errorf("expected %v, got %v", err, target)
+		}
+		target = nil
+		if !As(err, &target) || target != err {
+			t.Errorf("expected %v, got %v", err, target)
+		}
+	})
 	t.Run("with wrapped Error", func(t *testing.T) {
 		err := Template("inner error").New().Wrap(os.ErrNotExist)
 		werr := Template("outer error").New().Wrap(err)
@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"iter"
+	"maps"
+)
+
+// Field returns the value of the field key from the first error in the chain
+// that has the field set, starting from the outermost one.
+//
+// When the same key is set on several levels, the outermost level wins.
+// Note that Wrap copies the fields of a wrapped *Error into the wrapper,
+// over the fields inherited from the wrapper's template:
+//
+//	err := Template("b").Set("k", "outer").Wrap(Template("a").New().Set("k", "inner"))
+//	Field(err, "k")                // "inner": the wrapped value replaces the template's
+//	Field(err.Set("k", "new"), "k") // "new": set on the wrapper after Wrap
+//
+// Fields of errors wrapped by standard errors, like fmt.Errorf with %w,
+// aren't copied, so there the outermost value wins as is.
+func Field(err error, key string) (any, bool) {
+	for l := range chainLevels(err) {
+		if v, ok := l.fields[key]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Fields returns all fields found in the error chain merged into a new map,
+// with the same precedence as Field. It returns nil if no fields are found.
+func Fields(err error) map[string]any {
+	var res map[string]any
+	for l := range chainLevels(err) {
+		for k, v := range l.fields {
+			if res == nil {
+				res = make(map[string]any, len(l.fields))
+			}
+			if _, ok := res[k]; !ok {
+				res[k] = v
+			}
+		}
+	}
+	return res
+}
+
+// AllFields returns an iterator over the fields of every level in the error
+// chain, starting from the outermost level. It yields the level's position
+// in the chain, 0 for the outermost error, and a copy of the level's fields.
+// Levels without fields are skipped, and standard errors aren't counted
+// as levels.
+//
+//	for level, fields := range errors.AllFields(err) {
+//		log.Println(level, fields)
+//	}
+func AllFields(err error) iter.Seq2[int, map[string]any] {
+	return func(yield func(int, map[string]any) bool) {
+		level := 0
+		for l := range chainLevels(err) {
+			if len(l.fields) > 0 && !yield(level, maps.Clone(l.fields)) {
+				return
+			}
+			level++
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func fieldsChain() error {
+	inner := Template("reading body").New().Set("customerId", 7).Set("source", "inner")
+	outer := Template("order failed").Set("source", "outer").
+		Wrap(fmt.Errorf("decoding: %w", inner)).
+		Set("orderId", "A-1")
+	return fmt.Errorf("handler: %w", outer)
+}
+
+func TestField(t *testing.T) {
+	err := fieldsChain()
+
+	tests := []struct {
+		name     string
+		key      string
+		expected any
+		found    bool
+	}{
+		{"outer level", "orderId", "A-1", true},
+		{"inner level", "customerId", 7, true},
+		{"outer level wins", "source", "outer", true},
+		{"missing", "missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := Field(err, tt.key)
+			if ok != tt.found {
+				t.Errorf("expected found %v, got %v", tt.found, ok)
+			}
+			if v != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, v)
+			}
+		})
+	}
+
+	if _, ok := Field(nil, "orderId"); ok {
+		t.Error("expected no field for nil error")
+	}
+}
+
+func TestFields(t *testing.T) {
+	expected := map[string]any{
+		"orderId":    "A-1",
+		"customerId": 7,
+		"source":     "outer",
+	}
+
+	if got := Fields(fieldsChain()); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if got := Fields(io.EOF); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+
+	tmpl := Template("predefined error").Set("key", "value")
+	if got := Fields(tmpl); !reflect.DeepEqual(got, map[string]any{"key": "value"}) {
+		t.Errorf("expected template fields, got %v", got)
+	}
+}
+
+func TestField_WrapPrecedence(t *testing.T) {
+	err := Template("b").Set("k", "outer").Wrap(Template("a").New().Set("k", "inner"))
+	if v, _ := Field(err, "k"); v != "inner" {
+		t.Errorf("expected wrapped value to replace the template's, got %v", v)
+	}
+
+	err.Set("k", "wrapper")
+	if v, _ := Field(err, "k"); v != "wrapper" {
+		t.Errorf("expected value set after Wrap, got %v", v)
+	}
+	if v, _ := Field(err.Unwrap(), "k"); v != "inner" {
+		t.Errorf("expected wrapped error to keep its value, got %v", v)
+	}
+}
+
+func TestAllFields(t *testing.T) {
+	type level struct {
+		n      int
+		fields map[string]any
+	}
+
+	var got []level
+	for n, fields := range AllFields(fieldsChain()) {
+		got = append(got, level{n, fields})
+	}
+
+	expected := []level{
+		{0, map[string]any{"orderId": "A-1", "source": "outer"}},
+		{1, map[string]any{"customerId": 7, "source": "inner"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// Levels without fields are skipped but counted.
+	err := Template("outer").Wrap(Template("inner").New().Set("k", "v"))
+	err.fields = nil
+	for n, fields := range AllFields(err) {
+		if n != 1 || fields["k"] != "v" {
+			t.Errorf("expected level 1 with k, got %d %v", n, fields)
+		}
+	}
+
+	// Yielded maps are copies.
+	chain := fieldsChain()
+	for _, fields := range AllFields(chain) {
+		fields["orderId"] = "changed"
+		break
+	}
+	if v, _ := Field(chain, "orderId"); v != "A-1" {
+		t.Errorf("expected the error's fields to be unchanged, got %v", v)
+	}
+
+	var n int
+	for range AllFields(fieldsChain()) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("expected iteration to stop after 1 level, got %d", n)
+	}
+}
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Format implements fmt.Formatter.
+//
+// The verbs %s and %v print the error message, %q prints it quoted.
+// The verb %+v prints the error message followed by the fields with
+// sensitive values redacted and the stack trace.
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, e.Error())
+		if s.Flag('+') {
+			writeVerbose(s, e)
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(*errors.Error=%s)", verb, e.Error())
+	}
+}
+
+func writeVerbose(w io.Writer, e *Error) {
+	fields := redactFields(e, e.fields, &ErrorFormattingOptions{})
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		fmt.Fprintf(w, "\n\t%s=%v", k, fields[k])
+	}
+
+	for _, frame := range e.stack {
+		fmt.Fprintf(w, "\n%s\n\t%s", frame.Function, frame.File)
+	}
+}
@@ -0,0 +1,43 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestError_Format(t *testing.T) {
+	err := Template("payment failed").Sensitive("card", RedactMask).Wrap(io.EOF).
+		Set("card", "4111111111111111").
+		Set("amount", 10)
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%s", "payment failed: EOF"},
+		{"%v", "payment failed: EOF"},
+		{"%q", `"payment failed: EOF"`},
+		{"%d", "%!d(*errors.Error=payment failed: EOF)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, err); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("%+v", func(t *testing.T) {
+		got := fmt.Sprintf("%+v", err)
+		expected := "payment failed: EOF\n\tamount=10\n\tcard=************1111\n"
+		if !strings.HasPrefix(got, expected) {
+			t.Errorf("expected prefix %q, got %q", expected, got)
+		}
+		if !strings.Contains(got, "TestError_Format") {
+			t.Errorf("expected stack trace, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Formatter serializes errors in a particular output format.
+type Formatter interface {
+	// ContentType returns the media type of the output, like "application/json".
+	ContentType() string
+
+	// Format serializes the error. It returns nil if err is nil.
+	Format(err error, opts ...Option) []byte
+}
+
+// Names of the built-in formatters.
+const (
+	FormatJSON       = "json"
+	FormatIndentJSON = "json-indent"
+	FormatProblem    = "problem"
+	FormatText       = "text"
+	FormatLogfmt     = "logfmt"
+)
+
+type namedFormatter struct {
+	name string
+	f    Formatter
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = []namedFormatter{
+		{FormatJSON, jsonFormatter{}},
+		{FormatIndentJSON, jsonFormatter{indent: true}},
+		{FormatProblem, problemFormatter{}},
+		{FormatText, textFormatter{}},
+		{FormatLogfmt, logfmtFormatter{}},
+	}
+)
+
+// RegisterFormatter adds a formatter under the name or replaces
+// the formatter registered with the same name.
+//
+// Formatters are negotiated in the registration order,
+// built-in formatters first.
+func RegisterFormatter(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+
+	for i := range formatters {
+		if formatters[i].name == name {
+			formatters[i].f = f
+			return
+		}
+	}
+	formatters = append(formatters, namedFormatter{name: name, f: f})
+}
+
+// LookupFormatter returns the formatter registered under the name.
+func LookupFormatter(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	for _, nf := range formatters {
+		if nf.name == name {
+			return nf.f, true
+		}
+	}
+	return nil, false
+}
+
+// NegotiateFormatter returns the formatter best matching the Accept header
+// value, like "application/problem+json, application/json;q=0.9".
+// Media ranges like "text/*" and "*/*" match the first registered formatter
+// of the range. The JSON formatter is returned if nothing matches.
+func NegotiateFormatter(accept string) Formatter {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	for _, mt := range parseQualityList(accept) {
+		for _, nf := range formatters {
+			if mediaTypeMatches(mt, nf.f.ContentType()) {
+				return nf.f
+			}
+		}
+	}
+	return jsonFormatter{}
+}
+
+// mediaTypeMatches reports whether the content type
+// belongs to the media range.
+func mediaTypeMatches(mediaRange, contentType string) bool {
+	ct, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if mediaRange == "*/*" || mediaRange == ct {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(ct, prefix+"/")
+	}
+	return false
+}
+
+// jsonFormatter formats errors with ToJSON.
+type jsonFormatter struct {
+	indent bool
+}
+
+func (jsonFormatter) ContentType() string {
+	return "application/json"
+}
+
+func (f jsonFormatter) Format(err error, opts ...Option) []byte {
+	if f.indent {
+		opts = append(slices.Clip(opts), func(o *ErrorFormattingOptions) {
+			o.include |= IndentJSON
+		})
+	}
+	return ToJSON(err, opts...)
+}
+
+// ProblemTypeBase is the prefix of the "type" member of Problem Details.
+// The error code is appended to it, like "https://example.com/errors/CRM-0404".
+// If it's empty, the type is "about:blank".
+var ProblemTypeBase = ""
+
+// problemMembers lists the members of Problem Details written by problemFormatter.
+var problemMembers = []string{"type", "title", "status", "detail", "code", "severity", "fields", "wrapped", "stack"}
+
+// problemFormatter formats errors as Problem Details for HTTP APIs (RFC 9457).
+// The code, severity and fields are added as extension members, as well as
+// wrapped errors and stack if requested by the formatting options.
+type problemFormatter struct{}
+
+func (problemFormatter) ContentType() string {
+	return "application/problem+json"
+}
+
+func (problemFormatter) Format(err error, opts ...Option) []byte {
+	if err == nil {
+		return nil
+	}
+
+	var option ErrorFormattingOptions
+	for _, opt := range opts {
+		opt(&option)
+	}
+
+	serr := Serialize(err, opts...)
+	status := HTTPStatus(err)
+
+	res := map[string]any{
+		"type":   "about:blank",
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": serr.Message,
+	}
+	if ProblemTypeBase != "" && serr.Code != "" {
+		res["type"] = ProblemTypeBase + serr.Code
+	}
+	if serr.Code != "" {
+		res["code"] = serr.Code
+	}
+	if serr.Severity != "" {
+		res["severity"] = serr.Severity
+	}
+
+	fields, ferr := jsonFields(serr.Fields)
+	if ferr != nil && alarmer != nil {
+		alarmer.Alarm(ErrMarshalError.Wrap(ferr))
+	}
+
+	root := placeRootFields(nil, serr.root, func(key string) bool {
+		return slices.Contains(problemMembers, key)
+	}, "fields.", option.rootLevelCollisions)
+
+	for _, f := range root {
+		buf, err := appendFieldValue(nil, f.value)
+		if err != nil && alarmer != nil {
+			alarmer.Alarm(ErrMarshalError.Wrap(err).Set("field", f.path))
+		}
+		res[f.key] = json.RawMessage(buf)
+		if f.outer {
+			delete(fields, f.path)
+		}
+	}
+	if len(fields) > 0 {
+		res["fields"] = fields
+	}
+	if len(serr.Wrapped) > 0 {
+		res["wrapped"] = serr.Wrapped
+	}
+	if len(serr.Stack) > 0 {
+		res["stack"] = serr.Stack
+	}
+
+	var buf []byte
+	if option.include&IndentJSON != 0 {
+		buf, _ = json.MarshalIndent(res, "", "  ")
+	} else {
+		buf, _ = json.Marshal(res)
+	}
+	return buf
+}
+
+// textFormatter formats errors as plain text: the message and the code,
+// followed by the fields, as well as wrapped errors and stack if requested
+// by the formatting options.
+type textFormatter struct{}
+
+func (textFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (textFormatter) Format(err error, opts ...Option) []byte {
+	if err == nil {
+		return nil
+	}
+
+	serr := Serialize(err, opts...)
+
+	var buf bytes.Buffer
+	buf.WriteString(serr.Message)
+	if serr.Code != "" {
+		buf.WriteString(" [" + serr.Code + "]")
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(serr.Fields)) {
+		fmt.Fprintf(&buf, "\n\t%s=%v", k, serr.Fields[k])
+	}
+	for _, w := range serr.Wrapped {
+		buf.WriteString("\ncaused by: " + w.Message)
+	}
+	for _, frame := range serr.Stack {
+		fmt.Fprintf(&buf, "\n%s\n\t%s", frame.Function, frame.File)
+	}
+	return buf.Bytes()
+}
+
+// logfmtFormatter formats errors with ToLogfmt.
+type logfmtFormatter struct{}
+
+func (logfmtFormatter) ContentType() string {
+	return "application/logfmt"
+}
+
+func (logfmtFormatter) Format(err error, opts ...Option) []byte {
+	return ToLogfmt(err, opts...)
+}
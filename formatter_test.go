@@ -0,0 +1,189 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type upperFormatter struct{}
+
+func (upperFormatter) ContentType() string { return "application/x-upper" }
+
+func (upperFormatter) Format(err error, _ ...Option) []byte {
+	if err == nil {
+		return nil
+	}
+	return []byte("ERROR")
+}
+
+func TestLookupFormatter(t *testing.T) {
+	for _, name := range []string{FormatJSON, FormatIndentJSON, FormatProblem, FormatText, FormatLogfmt} {
+		if _, ok := LookupFormatter(name); !ok {
+			t.Errorf("expected formatter %q to be registered", name)
+		}
+	}
+
+	if _, ok := LookupFormatter("unknown"); ok {
+		t.Errorf("expected unknown formatter to be missing")
+	}
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	formattersMu.RLock()
+	saved := slices.Clone(formatters)
+	formattersMu.RUnlock()
+	t.Cleanup(func() {
+		formattersMu.Lock()
+		formatters = saved
+		formattersMu.Unlock()
+	})
+
+	RegisterFormatter("upper", upperFormatter{})
+
+	f, ok := LookupFormatter("upper")
+	if !ok {
+		t.Fatalf("expected formatter to be registered")
+	}
+	if got := string(f.Format(io.EOF)); got != "ERROR" {
+		t.Errorf("expected %q, got %q", "ERROR", got)
+	}
+	if got := NegotiateFormatter("application/x-upper"); got != f {
+		t.Errorf("expected negotiated formatter to be upperFormatter, got %T", got)
+	}
+
+	RegisterFormatter(FormatText, upperFormatter{})
+	if f, _ := LookupFormatter(FormatText); f.ContentType() != "application/x-upper" {
+		t.Errorf("expected text formatter to be replaced")
+	}
+}
+
+func TestNegotiateFormatter(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"application/json", "application/json"},
+		{"application/problem+json", "application/problem+json"},
+		{"application/json;q=0.5, application/problem+json", "application/problem+json"},
+		{"text/plain;charset=utf-8", "text/plain; charset=utf-8"},
+		{"text/*", "text/plain; charset=utf-8"},
+		{"application/logfmt", "application/logfmt"},
+		{"*/*", "application/json"},
+		{"image/png", "application/json"},
+		{"", "application/json"},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateFormatter(tt.accept).ContentType(); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.accept, tt.expected, got)
+		}
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").Severity(Tiny).StatusCode(404).
+		Wrap(io.EOF).
+		Set("customerId", 42)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     FormatJSON,
+			expected: `{"msg":"customer not found","severity":"tiny","code":"CRM-0404","statusCode":404,"fields":{"customerId":42}}`,
+		},
+		{
+			name:     FormatIndentJSON,
+			expected: "{\n  \"msg\": \"customer not found\",\n  \"severity\": \"tiny\",\n  \"code\": \"CRM-0404\",\n  \"statusCode\": 404,\n  \"fields\": {\n    \"customerId\": 42\n  }\n}",
+		},
+		{
+			name:     FormatText,
+			expected: "customer not found [CRM-0404]\n\tcustomerId=42",
+		},
+		{
+			name:     FormatText,
+			opts:     []Option{WithAttributes(AddWrappedErrors)},
+			expected: "customer not found [CRM-0404]\n\tcustomerId=42\ncaused by: EOF",
+		},
+		{
+			name:     FormatLogfmt,
+			expected: `msg="customer not found" severity=tiny code=CRM-0404 statusCode=404 fields.customerId=42`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := LookupFormatter(tt.name)
+			if got := string(f.Format(err, tt.opts...)); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+			if f.Format(nil) != nil {
+				t.Errorf("expected nil for nil error")
+			}
+		})
+	}
+}
+
+func TestProblemFormatter(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").Severity(Tiny).
+		New().
+		Set("customerId", 42).
+		Set("requestId", "req-1")
+
+	tests := []struct {
+		name     string
+		base     string
+		opts     []Option
+		expected map[string]any
+	}{
+		{
+			name: "default",
+			expected: map[string]any{
+				"type":     "about:blank",
+				"title":    "Bad Request",
+				"status":   float64(400),
+				"detail":   "customer not found",
+				"code":     "CRM-0404",
+				"severity": "tiny",
+				"fields":   map[string]any{"customerId": float64(42), "requestId": "req-1"},
+			},
+		},
+		{
+			name: "type base and root level fields",
+			base: "https://example.com/errors/",
+			opts: []Option{WithRootLevelFields([]string{"requestId"})},
+			expected: map[string]any{
+				"type":      "https://example.com/errors/CRM-0404",
+				"title":     "Bad Request",
+				"status":    float64(400),
+				"detail":    "customer not found",
+				"code":      "CRM-0404",
+				"severity":  "tiny",
+				"requestId": "req-1",
+				"fields":    map[string]any{"customerId": float64(42)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ProblemTypeBase = tt.base
+			t.Cleanup(func() { ProblemTypeBase = "" })
+
+			f, _ := LookupFormatter(FormatProblem)
+
+			var got map[string]any
+			if jerr := json.Unmarshal(f.Format(err, tt.opts...), &got); jerr != nil {
+				t.Fatalf("unexpected error: %v", jerr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,7 @@
 module github.com/axkit/errors
 
-go 1.22
+go 1.23.0
 
-require github.com/tidwall/sjson v1.2.5
+require google.golang.org/protobuf v1.36.8
 
-require (
-	github.com/tidwall/gjson v1.14.2 // indirect
-	github.com/tidwall/match v1.1.1 // indirect
-	github.com/tidwall/pretty v1.2.0 // indirect
-)
+require github.com/google/go-cmp v0.7.0 // indirect
@@ -0,0 +1,105 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultHTTPStatus is returned by HTTPStatus when neither the error chain
+// nor the configured mappings provide a status code.
+var DefaultHTTPStatus = http.StatusInternalServerError
+
+var (
+	statusMu         sync.RWMutex
+	severityStatus   = map[SeverityLevel]int{Tiny: http.StatusBadRequest}
+	codePrefixStatus map[string]int
+)
+
+// SetSeverityStatus sets the HTTP status code used by HTTPStatus for errors
+// of the severity level without an explicit status code.
+// By default Tiny errors get 400 Bad Request.
+// Passing zero status removes the mapping.
+func SetSeverityStatus(level SeverityLevel, status int) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	if status == 0 {
+		delete(severityStatus, level)
+		return
+	}
+	severityStatus[level] = status
+}
+
+// SetCodePrefixStatus sets the HTTP status code used by HTTPStatus for errors
+// whose code starts with the prefix and have no explicit status code.
+// The longest matching prefix wins. Passing zero status removes the mapping.
+//
+//	errors.SetCodePrefixStatus("AUTH-", http.StatusUnauthorized)
+func SetCodePrefixStatus(prefix string, status int) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	if status == 0 {
+		delete(codePrefixStatus, prefix)
+		return
+	}
+	if codePrefixStatus == nil {
+		codePrefixStatus = make(map[string]int)
+	}
+	codePrefixStatus[prefix] = status
+}
+
+// HTTPStatus returns the HTTP status code for the error response.
+// It returns 200 OK if err is nil. Otherwise the first of the following is used:
+//   - EffectiveStatusCode of the chain, if not zero;
+//   - the status code of the outermost error in the chain having it set;
+//   - the status mapped to the longest prefix of the first code found in the chain;
+//   - the status mapped to EffectiveSeverity of the chain;
+//   - DefaultHTTPStatus.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	if sc := EffectiveStatusCode(err); sc != 0 {
+		return sc
+	}
+
+	for l := range chainLevels(err) {
+		if l.metadata.statusCode != 0 {
+			return l.metadata.statusCode
+		}
+	}
+
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+
+	if code := firstCode(err); code != "" {
+		var match string
+		for prefix := range codePrefixStatus {
+			if strings.HasPrefix(code, prefix) && len(prefix) > len(match) {
+				match = prefix
+			}
+		}
+		if match != "" {
+			return codePrefixStatus[match]
+		}
+	}
+
+	if status, ok := severityStatus[EffectiveSeverity(err)]; ok {
+		return status
+	}
+
+	return DefaultHTTPStatus
+}
+
+// firstCode returns the code of the outermost error in the chain having it set.
+func firstCode(err error) string {
+	for l := range chainLevels(err) {
+		if l.metadata.code != "" {
+			return l.metadata.code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	SetCodePrefixStatus("AUTH-", http.StatusUnauthorized)
+	SetCodePrefixStatus("AUTH-03", http.StatusForbidden)
+	SetSeverityStatus(Medium, http.StatusServiceUnavailable)
+	t.Cleanup(func() {
+		SetCodePrefixStatus("AUTH-", 0)
+		SetCodePrefixStatus("AUTH-03", 0)
+		SetSeverityStatus(Medium, 0)
+	})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil error", nil, http.StatusOK},
+		{"standard error", io.EOF, http.StatusInternalServerError},
+		{"explicit status code", Template("not found").StatusCode(404).Severity(Tiny).New(), http.StatusNotFound},
+		{"inherited status code", Wrap(Template("not found").StatusCode(404).New(), "lookup failed"), http.StatusNotFound},
+		{"wrapped status code", Template("repo failed").Wrap(Template("nf").StatusCode(404).New()), http.StatusNotFound},
+		{"status code before code prefix", Template("login failed").Code("AUTH-0101").Wrap(fmt.Errorf("lookup: %w", Template("nf").StatusCode(404).New())), http.StatusNotFound},
+		{"code prefix", Template("token expired").Code("AUTH-0101").Severity(Tiny).New(), http.StatusUnauthorized},
+		{"longest code prefix", Template("access denied").Code("AUTH-0301").New(), http.StatusForbidden},
+		{"inner code", Template("login failed").Wrap(Template("token expired").Code("AUTH-0101").New()), http.StatusUnauthorized},
+		{"tiny severity", Template("invalid input").Severity(Tiny).New(), http.StatusBadRequest},
+		{"configured severity", fmt.Errorf("handler: %w", Template("busy").Severity(Medium).New()), http.StatusServiceUnavailable},
+		{"unmapped severity", Template("database is down").Severity(Critical).New(), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatus(tt.err); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus_Default(t *testing.T) {
+	DefaultHTTPStatus = http.StatusBadGateway
+	t.Cleanup(func() { DefaultHTTPStatus = http.StatusInternalServerError })
+
+	if got := HTTPStatus(io.EOF); got != http.StatusBadGateway {
+		t.Errorf("expected %d, got %d", http.StatusBadGateway, got)
+	}
+}
@@ -0,0 +1,494 @@
+package errors
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxPooledBufferSize limits the capacity of buffers returned to the pool,
+// so an occasional huge error doesn't pin memory.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+var optionsPool = sync.Pool{
+	New: func() any {
+		return new(ErrorFormattingOptions)
+	},
+}
+
+// getOptions returns formatting options with opts applied. The options
+// are pooled, since applying them makes a local value escape to the heap.
+func getOptions(opts []Option) *ErrorFormattingOptions {
+	o := optionsPool.Get().(*ErrorFormattingOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func putOptions(o *ErrorFormattingOptions) {
+	*o = ErrorFormattingOptions{}
+	optionsPool.Put(o)
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	*b = (*b)[:0]
+	bufferPool.Put(b)
+}
+
+// AppendJSON appends the JSON representation of the error to dst
+// and returns the extended buffer. The output is the same as ToJSON's.
+// The error is encoded straight from its chain, so in common cases nothing
+// is allocated if dst has enough capacity.
+func AppendJSON(dst []byte, err error, opts ...Option) []byte {
+	if err == nil {
+		return dst
+	}
+
+	option := getOptions(opts)
+	defer putOptions(option)
+
+	notify(EventSerialize, err)
+
+	// The error is serialized into buffers on the stack, sharing the fields
+	// of the error, so encoding it doesn't allocate in common cases.
+	var (
+		wb [8]SerializedError
+		rb [8]rootField
+	)
+	serr := serialize(err, option, true, wb[:0], rb[:0])
+
+	if option.include&IndentJSON == 0 {
+		return appendRootError(dst, &serr, option)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	*buf = appendRootError(*buf, &serr, option)
+	out := bytes.NewBuffer(dst)
+	_ = json.Indent(out, *buf, "", "  ")
+	return out.Bytes()
+}
+
+// WriteJSON writes the JSON representation of the error to w.
+// The output is the same as ToJSON's. Nothing is written if err is nil.
+func WriteJSON(w io.Writer, err error, opts ...Option) error {
+	if err == nil {
+		return nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	*buf = AppendJSON(*buf, err, opts...)
+	_, werr := w.Write(*buf)
+	return werr
+}
+
+// appendRootError appends the serialized error with the root-level fields
+// written at the top level of the object. If a field value can't be
+// encoded, it's replaced as described by appendFieldValue and the alarmer
+// is notified.
+func appendRootError(b []byte, serr *SerializedError, option *ErrorFormattingOptions) []byte {
+	schema := option.schema
+	if schema == nil {
+		schema = &defaultOutputSchema
+	}
+
+	var root []rootField
+	if len(serr.root) > 0 {
+		reserved := func(key string) bool {
+			if schema.isMember(key) {
+				return true
+			}
+			name, ok := strings.CutPrefix(key, schema.FieldPrefix)
+			if !schema.FlattenFields || !ok {
+				return false
+			}
+			_, ok = serr.Fields[name]
+			return ok && !isPromoted(serr.root, name)
+		}
+		var rb [8]rootField
+		root = placeRootFields(rb[:0], serr.root, reserved, cmp.Or(schema.Fields, "fields")+".", option.rootLevelCollisions)
+	}
+
+	b, err := appendSerializedError(b, serr, root, schema)
+	if err != nil && alarmer != nil {
+		alarmer.Alarm(ErrMarshalError.Wrap(err))
+	}
+	return b
+}
+
+// appendSerializedError appends the error as encoding/json would marshal
+// SerializedError, with the keys of the schema. The root fields are written
+// as members of the object itself.
+//
+// Values that can't be encoded are replaced, and the first encoding error
+// is returned along with the complete output.
+func appendSerializedError(b []byte, serr *SerializedError, root []rootField, schema *OutputSchema) ([]byte, error) {
+	var err, ferr error
+
+	// Small field sets are sorted on the stack.
+	var kb [16]string
+	keys := appendSortedKeys(kb[:0], serr.Fields)
+
+	b = append(b, '{')
+
+	if key := schema.Message; key != "" {
+		b = appendJSONKey(b, key)
+		b = appendJSONString(b, serr.Message)
+	}
+	if key := schema.Severity; key != "" && serr.Severity != "" {
+		b = appendJSONKey(b, key)
+		b = appendJSONString(b, serr.Severity)
+	}
+	if key := schema.Code; key != "" && serr.Code != "" {
+		b = appendJSONKey(b, key)
+		b = appendJSONString(b, serr.Code)
+	}
+	if key := schema.StatusCode; key != "" && serr.StatusCode != 0 {
+		b = appendJSONKey(b, key)
+		b = strconv.AppendInt(b, int64(serr.StatusCode), 10)
+	}
+
+	if schema.FlattenFields {
+		for _, k := range keys {
+			if isPromoted(root, k) || inFieldsObject(k, root, schema) {
+				continue
+			}
+			b = appendJSONKey(b, schema.FieldPrefix+k)
+			b, err = appendFieldValue(b, serr.Fields[k])
+			ferr = cmp.Or(ferr, err)
+		}
+	}
+
+	if key := schema.Fields; key != "" && countFields(serr.Fields, root, schema) > 0 {
+		b = appendJSONKey(b, key)
+		b = append(b, '{')
+		first := true
+		for _, k := range keys {
+			if !inFieldsObject(k, root, schema) {
+				continue
+			}
+			if !first {
+				b = append(b, ',')
+			}
+			first = false
+			b = appendJSONString(b, k)
+			b = append(b, ':')
+			b, err = appendFieldValue(b, serr.Fields[k])
+			ferr = cmp.Or(ferr, err)
+		}
+		b = append(b, '}')
+	}
+
+	if key := schema.Wrapped; key != "" && len(serr.Wrapped) > 0 {
+		b = appendJSONKey(b, key)
+		b = append(b, '[')
+		for i := range serr.Wrapped {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b, err = appendSerializedError(b, &serr.Wrapped[i], nil, schema)
+			ferr = cmp.Or(ferr, err)
+		}
+		b = append(b, ']')
+	}
+
+	if key := schema.Stack; key != "" && len(serr.Stack) > 0 {
+		b = appendJSONKey(b, key)
+		if schema.StackText {
+			b = appendJSONString(b, stackText(serr.Stack))
+		} else {
+			b = append(b, '[')
+			for i, f := range serr.Stack {
+				if i > 0 {
+					b = append(b, ',')
+				}
+				b = append(b, `{"func":`...)
+				b = appendJSONString(b, f.Function)
+				b = append(b, `,"file":`...)
+				b = appendJSONString(b, f.File)
+				b = append(b, `,"line":`...)
+				b = strconv.AppendInt(b, int64(f.Line), 10)
+				b = append(b, '}')
+			}
+			b = append(b, ']')
+		}
+	}
+
+	for _, f := range root {
+		b = appendJSONKey(b, f.key)
+		b, err = appendFieldValue(b, f.value)
+		ferr = cmp.Or(ferr, err)
+	}
+
+	b = append(b, '}')
+	return b, ferr
+}
+
+// appendJSONKey appends the object key, preceded by a comma
+// unless it's the first member of the object.
+func appendJSONKey(b []byte, key string) []byte {
+	if b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	b = appendJSONString(b, key)
+	return append(b, ':')
+}
+
+// inFieldsObject reports whether the field is written to the fields object.
+// Flattened fields colliding with other members stay in it.
+func inFieldsObject(key string, root []rootField, schema *OutputSchema) bool {
+	if isPromoted(root, key) {
+		return false
+	}
+	if !schema.FlattenFields {
+		return true
+	}
+	key = schema.FieldPrefix + key
+	return schema.isMember(key) || hasRootKey(root, key)
+}
+
+// countFields returns the number of fields left in the fields object.
+func countFields(fields map[string]any, root []rootField, schema *OutputSchema) int {
+	if len(root) == 0 && !schema.FlattenFields {
+		return len(fields)
+	}
+
+	n := 0
+	for k := range fields {
+		if inFieldsObject(k, root, schema) {
+			n++
+		}
+	}
+	return n
+}
+
+func sortedKeys(m map[string]any) []string {
+	return appendSortedKeys(make([]string, 0, len(m)), m)
+}
+
+// appendSortedKeys appends the keys of the map to dst in ascending order.
+func appendSortedKeys(dst []string, m map[string]any) []string {
+	n := len(dst)
+	for k := range m {
+		dst = append(dst, k)
+	}
+	slices.Sort(dst[n:])
+	return dst
+}
+
+// appendJSONValue appends the value as encoding/json would marshal it.
+// Common scalar types are encoded directly, others with encoding/json.
+func appendJSONValue(b []byte, v any) ([]byte, error) {
+	switch x := v.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendJSONString(b, x), nil
+	case bool:
+		return strconv.AppendBool(b, x), nil
+	case int:
+		return strconv.AppendInt(b, int64(x), 10), nil
+	case int8:
+		return strconv.AppendInt(b, int64(x), 10), nil
+	case int16:
+		return strconv.AppendInt(b, int64(x), 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(x), 10), nil
+	case int64:
+		return strconv.AppendInt(b, x, 10), nil
+	case uint:
+		return strconv.AppendUint(b, uint64(x), 10), nil
+	case uint8:
+		return strconv.AppendUint(b, uint64(x), 10), nil
+	case uint16:
+		return strconv.AppendUint(b, uint64(x), 10), nil
+	case uint32:
+		return strconv.AppendUint(b, uint64(x), 10), nil
+	case uint64:
+		return strconv.AppendUint(b, x, 10), nil
+	case float32:
+		return appendJSONFloat(b, float64(x), 32)
+	case float64:
+		return appendJSONFloat(b, x, 64)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return b, err
+	}
+	return append(b, buf...), nil
+}
+
+// appendFieldValue appends the field value as appendJSONValue does.
+// A value that can't be encoded is replaced by a string: "(chan int)"
+// for channels, functions and unsafe pointers, the text of
+// encoding.TextMarshaler, or the fmt representation otherwise, which
+// uses the Error and String methods. The encoding error is returned
+// along with the replacement.
+//
+// Errors that don't implement json.Marshaler are always written
+// as their messages, since encoding/json would produce "{}" for most.
+func appendFieldValue(b []byte, v any) ([]byte, error) {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+	case error:
+		return appendJSONString(b, fmt.Sprint(v)), nil
+	}
+
+	start := len(b)
+	b, err := appendJSONValue(b, v)
+	if err == nil {
+		return b, nil
+	}
+	return appendJSONString(b[:start], fieldFallback(v)), err
+}
+
+// jsonFields returns a copy of fields with values encoded
+// by appendFieldValue, ready for encoding/json. The first encoding
+// error is returned along with the complete result.
+func jsonFields(fields map[string]any) (map[string]any, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	var ferr error
+	res := make(map[string]any, len(fields))
+	for k, v := range fields {
+		buf, err := appendFieldValue(nil, v)
+		ferr = cmp.Or(ferr, err)
+		res[k] = json.RawMessage(buf)
+	}
+	return res, ferr
+}
+
+// fieldFallback returns the string replacing a value
+// that can't be encoded to JSON. Composite values are replaced by
+// a type marker rather than printed by fmt, which doesn't detect
+// cycles and could overflow the stack.
+func fieldFallback(v any) string {
+	switch x := v.(type) {
+	case fmt.Stringer:
+		return x.String()
+	case encoding.TextMarshaler:
+		if text, err := x.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer,
+		reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer, reflect.Interface:
+		return "(" + rv.Type().String() + ")"
+	}
+	return fmt.Sprint(v)
+}
+
+// appendJSONFloat follows the float encoding of encoding/json.
+func appendJSONFloat(b []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return b, &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString follows the string encoding of encoding/json,
+// including the escaping of HTML characters.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
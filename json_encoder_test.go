@@ -0,0 +1,377 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendSerializedError(t *testing.T) {
+	tests := []struct {
+		name string
+		serr SerializedError
+	}{
+		{
+			name: "message only",
+			serr: SerializedError{Message: "failure"},
+		},
+		{
+			name: "escaping",
+			serr: SerializedError{Message: "a \"quoted\" <b>&</b> \\ \b\f\n\r\t\x00\x1f    \xff ü 日本"},
+		},
+		{
+			name: "all members",
+			serr: SerializedError{
+				Message:    "customer not found",
+				Severity:   "tiny",
+				Code:       "CRM-0404",
+				StatusCode: 404,
+				Fields:     map[string]any{"customerId": 42, "email": "john@example.com"},
+				Wrapped: []SerializedError{
+					{Message: "customer not found", Severity: "tiny", Code: "CRM-0404", StatusCode: 404},
+					{Message: "EOF"},
+				},
+				Stack: []StackFrame{
+					{Function: "main.main", File: "/app/main.go:10", Line: 10},
+					{Function: "runtime.main", File: "/go/src/runtime/proc.go:283", Line: 283},
+				},
+			},
+		},
+		{
+			name: "field types",
+			serr: SerializedError{
+				Message: "types",
+				Fields: map[string]any{
+					"nil":     nil,
+					"bool":    true,
+					"int":     -1,
+					"int8":    int8(-8),
+					"int16":   int16(-16),
+					"int32":   int32(-32),
+					"int64":   int64(math.MinInt64),
+					"uint":    uint(1),
+					"uint8":   uint8(8),
+					"uint16":  uint16(16),
+					"uint32":  uint32(32),
+					"uint64":  uint64(math.MaxUint64),
+					"f32":     float32(3.14),
+					"f32e":    float32(1e-7),
+					"f64":     0.1,
+					"f64zero": 0.0,
+					"f64neg":  -2.5,
+					"f64tiny": 1e-9,
+					"f64huge": 1e21,
+					"f64big":  123456789012345678.0,
+					"slice":   []string{"a", "b"},
+					"map":     map[string]any{"nested": 1},
+					"struct":  point{1, 2},
+					"time":    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					"bytes":   []byte("raw"),
+					"number":  json.Number("12.50"),
+					"<key>":   "html key",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := json.Marshal(&tt.serr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := appendSerializedError(nil, &tt.serr, nil, &defaultOutputSchema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+			}
+		})
+	}
+}
+
+func TestAppendJSONString(t *testing.T) {
+	inputs := []string{"", "plain", "\u007f", "\x7f\x80", "aéb", "\U0001F600", "\xed\xa0\x80"}
+	for c := 0; c < 0x80; c++ {
+		inputs = append(inputs, string(rune(c)))
+	}
+
+	for _, s := range inputs {
+		expected, _ := json.Marshal(s)
+		if got := appendJSONString(nil, s); !bytes.Equal(got, expected) {
+			t.Errorf("%q: expected %s, got %s", s, expected, got)
+		}
+	}
+}
+
+func TestToJSON_RootLevelFields(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").New().
+		Set("customerId", 42).
+		Set("requestId", "req-1").
+		Set("traceId", "abc")
+
+	tests := []struct {
+		name     string
+		fields   []string
+		expected string
+	}{
+		{
+			name:     "in option order",
+			fields:   []string{"traceId", "requestId", "missing"},
+			expected: `{"msg":"customer not found","severity":"unknown","code":"CRM-0404","fields":{"customerId":42},"traceId":"abc","requestId":"req-1"}`,
+		},
+		{
+			name:     "all fields",
+			fields:   []string{"customerId", "requestId", "traceId"},
+			expected: `{"msg":"customer not found","severity":"unknown","code":"CRM-0404","customerId":42,"requestId":"req-1","traceId":"abc"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(ToJSON(err, WithRootLevelFields(tt.fields)))
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToJSON_RootLevelMembers(t *testing.T) {
+	err := Template("failure").New().
+		Set("code", "X-1").
+		Set("stack", "kept")
+
+	expected := `{"msg":"failure","severity":"unknown","fields.code":"X-1","fields.stack":"kept"}`
+	if got := string(ToJSON(err, WithRootLevelFields([]string{"code", "stack"}))); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestToJSON_Indent(t *testing.T) {
+	err := Template("failure").Code("E1").Wrap(io.EOF).Set("n", 1)
+
+	serr := Serialize(err, WithAttributes(AddWrappedErrors))
+	expected, _ := json.MarshalIndent(serr, "", "  ")
+
+	if got := ToJSON(err, WithAttributes(AddWrappedErrors|IndentJSON)); !bytes.Equal(got, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestToJSON_UnsupportedField(t *testing.T) {
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	err := Template("failure").New().
+		Set("ch", make(chan int)).
+		Set("nan", math.NaN())
+
+	expected := `{"msg":"failure","severity":"unknown","fields":{"ch":"(chan int)","nan":"NaN"}}`
+	if got := string(ToJSON(err)); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if !mock.called || !Is(mock.err, ErrMarshalError) {
+		t.Errorf("expected ErrMarshalError alarm, got %v", mock.err)
+	}
+}
+
+func TestAppendJSON(t *testing.T) {
+	err := Template("failure").New()
+
+	got := AppendJSON([]byte("prefix "), err)
+	if expected := `prefix {"msg":"failure","severity":"unknown"}`; string(got) != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	got = AppendJSON([]byte("prefix "), err, WithAttributes(IndentJSON))
+	if expected := "prefix {\n  \"msg\": \"failure\",\n  \"severity\": \"unknown\"\n}"; string(got) != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	if got := AppendJSON([]byte("x"), nil); string(got) != "x" {
+		t.Errorf("expected %q, got %q", "x", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Template("failure").New()
+	if werr := WriteJSON(&buf, err); werr != nil {
+		t.Fatalf("unexpected error: %v", werr)
+	}
+	if !bytes.Equal(buf.Bytes(), ToJSON(err)) {
+		t.Errorf("expected %s, got %s", ToJSON(err), buf.Bytes())
+	}
+
+	buf.Reset()
+	if werr := WriteJSON(&buf, nil); werr != nil || buf.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %q (%v)", buf.String(), werr)
+	}
+}
+
+func benchmarkError() *Error {
+	return Template("customer not found").Code("CRM-0404").Severity(Tiny).StatusCode(404).
+		Wrap(io.EOF).
+		Set("customerId", 42).
+		Set("email", "john@example.com").
+		Set("requestId", "req-1")
+}
+
+var benchmarkOptions = []Option{
+	WithAttributes(AddWrappedErrors | AddFields),
+	WithRootLevelFields([]string{"requestId"}),
+}
+
+func BenchmarkToJSON(b *testing.B) {
+	err := benchmarkError()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ToJSON(err, benchmarkOptions...)
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	err := benchmarkError()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = WriteJSON(io.Discard, err, benchmarkOptions...)
+	}
+}
+
+func TestAppendJSON_Allocs(t *testing.T) {
+	err := benchmarkError()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"no options", nil},
+		{"root level fields", benchmarkOptions},
+		{"server output", []Option{WithAttributes(ServerOutputFormat)}},
+		{"output schema", []Option{WithAttributes(AddWrappedErrors | AddFields), WithOutputSchema(ECSOutputSchema)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := make([]byte, 0, 4096)
+			allocs := testing.AllocsPerRun(100, func() {
+				buf = AppendJSON(buf[:0], err, tt.opts...)
+			})
+			if allocs != 0 {
+				t.Errorf("AppendJSON: expected no allocations, got %v", allocs)
+			}
+
+			allocs = testing.AllocsPerRun(100, func() {
+				_ = WriteJSON(io.Discard, err, tt.opts...)
+			})
+			if allocs != 0 {
+				t.Errorf("WriteJSON: expected no allocations, got %v", allocs)
+			}
+		})
+	}
+}
+
+type stringerValue struct {
+	Ch chan int
+}
+
+func (stringerValue) String() string { return "stringer" }
+
+type textValue struct {
+	F func()
+}
+
+func (textValue) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+type marshalerValue struct{}
+
+func (marshalerValue) MarshalJSON() ([]byte, error) { return []byte(`{"custom":true}`), nil }
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) { return nil, io.ErrUnexpectedEOF }
+
+func (failingMarshaler) String() string { return "failing" }
+
+type cyclic struct {
+	Name string
+	Next *cyclic
+}
+
+func TestAppendFieldValue(t *testing.T) {
+	loop := &cyclic{Name: "loop"}
+	loop.Next = loop
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = cyclicMap
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+		failed   bool
+	}{
+		{"string", "plain", `"plain"`, false},
+		{"json marshaler", marshalerValue{}, `{"custom":true}`, false},
+		{"text marshaler", textValue{}, `"text"`, false},
+		{"error", io.EOF, `"EOF"`, false},
+		{"error implementing json.Marshaler", Template("inner").New(), `{"msg":"inner","severity":"unknown"}`, false},
+		{"stringer", stringerValue{Ch: make(chan int)}, `"stringer"`, true},
+		{"failing marshaler", failingMarshaler{}, `"failing"`, true},
+		{"channel", make(chan int), `"(chan int)"`, true},
+		{"func", func() {}, `"(func())"`, true},
+		{"nan", math.Inf(1), `"+Inf"`, true},
+		{"cyclic", loop, `"(*errors.cyclic)"`, true},
+		{"cyclic map", cyclicMap, `"(map[string]interface {})"`, true},
+		{"struct map key", map[point]int{{1, 2}: 3}, `"(map[errors.point]int)"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := appendFieldValue(nil, tt.value)
+			if (err != nil) != tt.failed {
+				t.Errorf("expected failure %v, got %v", tt.failed, err)
+			}
+			if !bytes.HasPrefix(got, []byte(tt.expected)) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+			if !json.Valid(got) {
+				t.Errorf("expected valid JSON, got %s", got)
+			}
+		})
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestProblemFormatter_UnsupportedField(t *testing.T) {
+	err := Template("failure").New().
+		Set("ch", make(chan int)).
+		Set("n", 1)
+
+	f, _ := LookupFormatter(FormatProblem)
+
+	var got struct {
+		Fields map[string]any `json:"fields"`
+	}
+	if jerr := json.Unmarshal(f.Format(err), &got); jerr != nil {
+		t.Fatalf("unexpected error: %v", jerr)
+	}
+
+	expected := map[string]any{"ch": "(chan int)", "n": float64(1)}
+	if !reflect.DeepEqual(got.Fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, got.Fields)
+	}
+}
@@ -1,11 +1,6 @@
 package errors
 
-import (
-	"encoding/json"
-	"slices"
-
-	"github.com/tidwall/sjson"
-)
+import "bytes"
 
 var ErrMarshalError = Template("error marshaling failed").Severity(Critical).StatusCode(500)
 
@@ -26,12 +21,22 @@ const (
 	AddWrappedErrors
 
 	IndentJSON
+
+	// AddSensitive - add values of sensitive fields without redaction.
+	AddSensitive
 )
 
 type ErrorFormattingOptions struct {
 	stopStackOn     string
 	include         ErrorSerializationRule
 	rootLevelFields []string
+	redactor        Redactor
+	catalog         *Catalog
+	locale          string
+	schema          *OutputSchema
+
+	rootLevelKeys       map[string]string
+	rootLevelCollisions RootLevelCollision
 }
 
 type Option func(*ErrorFormattingOptions)
@@ -52,12 +57,6 @@ func WithAttributes(rule ErrorSerializationRule) Option {
 	}
 }
 
-func WithRootLevelFields(fields []string) Option {
-	return func(e *ErrorFormattingOptions) {
-		e.rootLevelFields = fields
-	}
-}
-
 const (
 	ServerOutputFormat      = AddProtected | AddStack | AddFields | AddWrappedErrors
 	ServerDebugOutputFormat = AddProtected | AddStack | AddFields | AddWrappedErrors | IndentJSON
@@ -74,6 +73,13 @@ type SerializedError struct {
 	Fields     map[string]any    `json:"fields,omitempty"`
 	Wrapped    []SerializedError `json:"wrapped,omitempty"`
 	Stack      []StackFrame      `json:"stack,omitempty"`
+
+	// Schema holds the keys and layout set by WithOutputSchema, resolved
+	// as ToJSON uses them, so custom serializers can follow the schema.
+	// Serialize sets it to the default schema if none is given.
+	Schema *OutputSchema `json:"-"`
+
+	root []rootField
 }
 
 // Serialize serializes the error to a SerializedError struct.
@@ -86,114 +92,102 @@ func Serialize(err error, opts ...Option) *SerializedError {
 	for _, opt := range opts {
 		opt(&option)
 	}
-	return serialize(err, option)
+	notify(EventSerialize, err)
+
+	schema := defaultOutputSchema
+	if option.schema != nil {
+		schema = *option.schema
+	}
+
+	res := serialize(err, &option, false, nil, nil)
+	res.Schema = &schema
+	for i := range res.Wrapped {
+		res.Wrapped[i].Schema = &schema
+	}
+	return &res
 }
 
-func serialize(err error, option ErrorFormattingOptions) *SerializedError {
+// serialize returns the serialized error. Wrapped errors are appended to
+// wrapped and root-level fields to root, so the caller may provide buffers
+// for them. If shared is true, the result may refer to the fields of
+// the error instead of their copy.
+func serialize(err error, option *ErrorFormattingOptions, shared bool, wrapped []SerializedError, root []rootField) SerializedError {
+	var we *Error
 	switch e := err.(type) {
 	case *ErrorTemplate:
-		return serializeError(e.New(), option)
+		we = e.New()
 	case *Error:
-		return serializeError(e, option)
+		we = e
 	case interface{ Error() string }:
-		return &SerializedError{
-			Message: e.Error(),
-		}
-	}
-	panic("unsupported error type")
-}
-
-func serializeError(we *Error, option ErrorFormattingOptions) *SerializedError {
-
-	resp := SerializedError{
-		Message:    we.message,
-		Severity:   we.severity.String(),
-		Code:       we.code,
-		StatusCode: we.statusCode,
-		Fields:     we.fields,
-		Wrapped:    nil,
-		Stack:      nil,
+		return SerializedError{Message: e.Error()}
+	default:
+		panic("unsupported error type")
+	}
+
+	var serr SerializedError
+	serr.Message = formatMessage(we, option.localize(we.code, we.message), option)
+	serr.Severity = EffectiveSeverity(we).String()
+	serr.Code = we.code
+	serr.StatusCode = EffectiveStatusCode(we)
+	if shared && len(we.fields) > 0 && !hasRedactedFields(we, we.fields, option) {
+		serr.Fields = we.fields
+	} else {
+		serr.Fields = redactFields(we, we.fields, option)
 	}
-
 	if option.include&AddWrappedErrors != 0 {
-		for _, xe := range we.WrappedErrors() {
-			tx := SerializedError{
-				Message:    xe.message,
-				Severity:   xe.severity.String(),
-				Code:       xe.code,
-				StatusCode: xe.statusCode,
-			}
-			if xe.pureWrapper && tx.Message == "" {
-				tx.Message = xe.err.Error()
-			}
-
-			resp.Wrapped = append(resp.Wrapped, tx)
-		}
-
-		//resp.Wrapped = resp.Wrapped[1:]
+		serr.Wrapped = appendWrapped(wrapped, we, option)
 	}
-
 	if option.include&AddStack != 0 && len(we.stack) > 0 {
-		resp.Stack = we.stack
+		serr.Stack = we.stack
 	}
-	return &resp
+	serr.root = appendPromotedFields(root, we, option)
+	return serr
 }
 
-// ToJSON serializes the error to JSON format.
-func ToJSON(err error, opts ...Option) []byte {
-
-	if err == nil {
-		return nil
-	}
-
-	var option ErrorFormattingOptions
-	for _, opt := range opts {
-		opt(&option)
+// appendWrapped appends the levels of the chain listed as wrapped to dst.
+func appendWrapped(dst []SerializedError, we *Error, option *ErrorFormattingOptions) []SerializedError {
+	if !we.pureWrapper {
+		dst = append(dst, serializeWrapped(we, option))
 	}
-
-	serr := serialize(err, option)
-
-	var rootLevelFields map[string]any
-
-	if len(option.rootLevelFields) > 0 {
-		rootLevelFields = make(map[string]any)
-		for k, v := range serr.Fields {
-			if slices.Contains(option.rootLevelFields, k) {
-				rootLevelFields[k] = v
-				delete(serr.Fields, k)
-			}
+	for e := we.err; e != nil; {
+		x, ok := e.(*Error)
+		if !ok {
+			return append(dst, SerializedError{Message: e.Error(), Severity: Unknown.String()})
 		}
+		if !x.metadata.empty() {
+			dst = append(dst, serializeWrapped(x, option))
+		}
+		e = x.err
 	}
+	return dst
+}
 
-	var buf []byte
-	var marshalErr error
-	if option.include&IndentJSON != 0 {
-		buf, marshalErr = json.MarshalIndent(serr, "", "  ")
-	} else {
-		buf, marshalErr = json.Marshal(serr)
+// serializeWrapped returns a single level of the chain listed as wrapped.
+func serializeWrapped(xe *Error, option *ErrorFormattingOptions) SerializedError {
+	res := SerializedError{
+		Message:    formatMessage(xe, option.localize(xe.code, xe.message), option),
+		Severity:   xe.severity.String(),
+		Code:       xe.code,
+		StatusCode: xe.statusCode,
 	}
-
-	if marshalErr == nil {
-		if len(rootLevelFields) > 0 {
-			for k, v := range rootLevelFields {
-				buf, _ = sjson.SetBytes(buf, k, v)
-			}
-		}
-		return buf
+	if xe.pureWrapper && res.Message == "" && xe.err != nil {
+		res.Message = xe.err.Error()
 	}
+	return res
+}
 
-	if alarmer != nil {
-		alarmer.Alarm(ErrMarshalError.Wrap(marshalErr))
+// ToJSON serializes the error to JSON format.
+//
+// The JSON is produced by a hand-written encoder equivalent to encoding/json.
+// Use AppendJSON or WriteJSON to avoid copying the output.
+func ToJSON(err error, opts ...Option) []byte {
+	if err == nil {
+		return nil
 	}
 
-	// Marshalling can fail if Fields contains non-serializable values.
-	if len(serr.Fields) > 0 {
-		serr.Fields = nil
-		buf, marshalErr = json.Marshal(serr)
-		if marshalErr == nil {
-			return buf
-		}
-	}
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	return []byte(`{"msg":"` + marshalErr.Error() + `", "severity": "critical"}`)
+	*buf = AppendJSON(*buf, err, opts...)
+	return bytes.Clone(*buf)
 }
@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Key is a typed field key. It binds a field name to the Go type of its value,
+// so that the value can be attached with With and read back with Get without
+// type assertions.
+//
+//	var CustomerID = errors.Key[int64]("customerId")
+//
+//	err := ErrCustomerNotFound.New().With(CustomerID, 42)
+//	id, ok := errors.Get(err, CustomerID)
+//
+// A Key shares the field namespace with Set: Key[int64]("customerId") and
+// Set("customerId", ...) refer to the same field.
+type Key[T any] string
+
+// FieldKey is implemented by Key of any type. It allows With to accept
+// typed keys regardless of their type parameter.
+type FieldKey interface {
+	fmt.Stringer
+
+	// convert returns the value converted to the key's type
+	// or an error if it can't be represented exactly.
+	convert(value any) (any, error)
+
+	// typeName returns the name of the key's type.
+	typeName() string
+}
+
+// String returns the field name.
+func (k Key[T]) String() string {
+	return string(k)
+}
+
+// Convert returns the value as T. Numeric values are converted between
+// numeric types if the value is represented exactly: With(Key[int8], 300)
+// and With(Key[int64], 3.9) fail, while With(Key[int64], 42) and
+// With(Key[float32], 0.5) succeed. Values of other types fail.
+// The error matches ErrFieldSchemaViolation.
+func (k Key[T]) Convert(value any) (T, error) {
+	v, err := k.convert(value)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+func (k Key[T]) convert(value any) (any, error) {
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+
+	to := reflect.TypeFor[T]()
+	if value != nil {
+		rv := reflect.ValueOf(value)
+		if isNumericKind(rv.Kind()) && isNumericKind(to.Kind()) {
+			if cv, ok := convertNumber(rv, to); ok {
+				return cv.Interface(), nil
+			}
+		}
+	}
+
+	return nil, ErrFieldSchemaViolation.
+		Wrap(New("field "+strconv.Quote(string(k))+" is not "+to.String()+": "+fmt.Sprintf("%T", value))).
+		Set("mistyped", []string{string(k)})
+}
+
+func (k Key[T]) typeName() string {
+	return reflect.TypeFor[T]().String()
+}
+
+// convertNumber converts the numeric value to the numeric type
+// if the conversion keeps the value. Conversions between floating-point
+// types may round, but must not overflow.
+func convertNumber(rv reflect.Value, to reflect.Type) (reflect.Value, bool) {
+	cv := rv.Convert(to)
+
+	if rv.CanFloat() && cv.CanFloat() {
+		f := rv.Float()
+		return cv, math.IsInf(f, 0) || math.IsNaN(f) || !math.IsInf(cv.Float(), 0)
+	}
+
+	// A lossless conversion survives the round trip.
+	return cv, cv.Convert(rv.Type()).Equal(rv) && sameSign(rv, cv)
+}
+
+// sameSign reports whether both numbers are negative or both are not,
+// which catches conversions like int8(-1) to uint64 and back.
+func sameSign(a, b reflect.Value) bool {
+	return isNegative(a) == isNegative(b)
+}
+
+func isNegative(v reflect.Value) bool {
+	switch {
+	case v.CanInt():
+		return v.Int() < 0
+	case v.CanFloat():
+		return v.Float() < 0
+	}
+	return false
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// With adds or updates a typed key-value pair in the error's fields.
+// If the value can't be converted to the key's type, as described by
+// Key.Convert, it isn't stored and the failure is passed to
+// FieldViolationHandler.
+func (e *Error) With(key FieldKey, value any) *Error {
+	v, err := key.convert(value)
+	if err != nil {
+		reportFieldViolation(err)
+		return e
+	}
+	return e.Set(key.String(), v)
+}
+
+// With adds a typed key-value pair to the template's fields.
+// If the value can't be converted to the key's type, as described by
+// Key.Convert, it isn't stored and the failure is passed to
+// FieldViolationHandler.
+func (et *ErrorTemplate) With(key FieldKey, value any) *ErrorTemplate {
+	v, err := key.convert(value)
+	if err != nil {
+		reportFieldViolation(err)
+		return et
+	}
+	return et.Set(key.String(), v)
+}
+
+// Get returns the value of the typed field key from the first error in the
+// chain that has the field set, starting from the outermost one.
+// The second result is false if the field is not found or its value
+// can't be converted to T as described by Key.Convert.
+func Get[T any](err error, key Key[T]) (T, bool) {
+	var zero T
+
+	v, ok := Field(err, string(key))
+	if !ok {
+		return zero, false
+	}
+
+	cv, cerr := key.Convert(v)
+	if cerr != nil {
+		return zero, false
+	}
+	return cv, true
+}
@@ -0,0 +1,204 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestKey_String(t *testing.T) {
+	key := Key[int64]("customerId")
+	if key.String() != "customerId" {
+		t.Errorf("expected %q, got %q", "customerId", key.String())
+	}
+}
+
+func TestError_With(t *testing.T) {
+	customerID := Key[int64]("customerId")
+	email := Key[string]("email")
+
+	err := Template("customer not found").New().
+		With(customerID, 42).
+		With(email, "john@example.com")
+
+	if v, ok := err.fields["customerId"].(int64); !ok || v != 42 {
+		t.Errorf("expected int64 42, got %T %v", err.fields["customerId"], err.fields["customerId"])
+	}
+
+	if v := err.fields["email"]; v != "john@example.com" {
+		t.Errorf("expected %q, got %v", "john@example.com", v)
+	}
+}
+
+func TestErrorTemplate_With(t *testing.T) {
+	retries := Key[uint8]("retries")
+	et := Template("service unavailable").With(retries, 3)
+
+	if v, ok := Get(et, retries); !ok || v != 3 {
+		t.Errorf("expected 3, got %v (found: %v)", v, ok)
+	}
+
+	if v, ok := Get(et.New(), retries); !ok || v != 3 {
+		t.Errorf("expected 3, got %v (found: %v)", v, ok)
+	}
+}
+
+func TestGet(t *testing.T) {
+	customerID := Key[int64]("customerId")
+	orderID := Key[string]("orderId")
+	missing := Key[bool]("missing")
+
+	inner := Template("customer not found").New().With(customerID, 42)
+	outer := Template("order failed").Wrap(inner)
+	outer.Set("orderId", "A-1")
+
+	std := fmt.Errorf("handler: %w", Wrap(io.EOF, "reading body").Set("customerId", 7))
+
+	tests := []struct {
+		name     string
+		got      func() (any, bool)
+		expected any
+		found    bool
+	}{
+		{
+			name:     "outermost level",
+			got:      func() (any, bool) { return Get(outer, orderID) },
+			expected: "A-1",
+			found:    true,
+		},
+		{
+			name:     "inner level",
+			got:      func() (any, bool) { return Get(inner, customerID) },
+			expected: int64(42),
+			found:    true,
+		},
+		{
+			name:     "wrapped in standard error",
+			got:      func() (any, bool) { return Get(std, customerID) },
+			expected: int64(7),
+			found:    true,
+		},
+		{
+			name:     "missing key",
+			got:      func() (any, bool) { return Get(outer, missing) },
+			expected: false,
+			found:    false,
+		},
+		{
+			name:     "type mismatch",
+			got:      func() (any, bool) { return Get(outer, Key[int]("orderId")) },
+			expected: 0,
+			found:    false,
+		},
+		{
+			name:     "nil error",
+			got:      func() (any, bool) { return Get(nil, orderID) },
+			expected: "",
+			found:    false,
+		},
+		{
+			name:     "standard error",
+			got:      func() (any, bool) { return Get(io.EOF, orderID) },
+			expected: "",
+			found:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := tt.got()
+			if ok != tt.found {
+				t.Errorf("expected found %v, got %v", tt.found, ok)
+			}
+			if v != tt.expected {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.expected, tt.expected, v, v)
+			}
+		})
+	}
+}
+
+func TestKey_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		convert  func() (any, error)
+		expected any
+		ok       bool
+	}{
+		{"same type", func() (any, error) { return Key[int64]("k").Convert(int64(42)) }, int64(42), true},
+		{"untyped constant", func() (any, error) { return Key[int64]("k").Convert(42) }, int64(42), true},
+		{"fits int8", func() (any, error) { return Key[int8]("k").Convert(-128) }, int8(-128), true},
+		{"overflows int8", func() (any, error) { return Key[int8]("k").Convert(300) }, int8(0), false},
+		{"negative to unsigned", func() (any, error) { return Key[uint64]("k").Convert(-1) }, uint64(0), false},
+		{"unsigned overflows signed", func() (any, error) { return Key[int64]("k").Convert(uint64(math.MaxUint64)) }, int64(0), false},
+		{"whole float to int", func() (any, error) { return Key[int64]("k").Convert(3.0) }, int64(3), true},
+		{"fractional float to int", func() (any, error) { return Key[int64]("k").Convert(3.9) }, int64(0), false},
+		{"inexact int to float", func() (any, error) { return Key[float64]("k").Convert(int64(1<<53 + 1)) }, float64(0), false},
+		{"float64 to float32", func() (any, error) { return Key[float32]("k").Convert(0.1) }, float32(0.1), true},
+		{"float32 overflow", func() (any, error) { return Key[float32]("k").Convert(1e300) }, float32(0), false},
+		{"string to int", func() (any, error) { return Key[int64]("k").Convert("abc") }, int64(0), false},
+		{"nil", func() (any, error) { return Key[string]("k").Convert(nil) }, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.convert()
+			if (err == nil) != tt.ok {
+				t.Fatalf("expected success %v, got error %v", tt.ok, err)
+			}
+			if err != nil && !Is(err, ErrFieldSchemaViolation) {
+				t.Errorf("expected ErrFieldSchemaViolation, got %v", err)
+			}
+			if v != tt.expected {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.expected, tt.expected, v, v)
+			}
+		})
+	}
+}
+
+func TestError_WithRejectedValue(t *testing.T) {
+	var violations []error
+	prev := FieldViolationHandler
+	FieldViolationHandler = func(err error) { violations = append(violations, err) }
+	t.Cleanup(func() { FieldViolationHandler = prev })
+
+	retries := Key[int8]("retries")
+	customerID := Key[int64]("customerId")
+
+	err := Template("failure").New().
+		With(retries, 300).
+		With(customerID, "abc")
+	et := Template("failure").With(customerID, 3.9)
+
+	if _, ok := err.fields["retries"]; ok {
+		t.Errorf("expected overflowing value to be rejected, got %v", err.fields["retries"])
+	}
+	if _, ok := err.fields["customerId"]; ok {
+		t.Errorf("expected mistyped value to be rejected, got %v", err.fields["customerId"])
+	}
+	if _, ok := et.fields["customerId"]; ok {
+		t.Errorf("expected fractional value to be rejected, got %v", et.fields["customerId"])
+	}
+
+	if len(violations) != 3 {
+		t.Fatalf("expected 3 violations, got %d", len(violations))
+	}
+	if v, _ := Field(violations[0], "mistyped"); !reflect.DeepEqual(v, []string{"retries"}) {
+		t.Errorf("expected mistyped retries, got %v", v)
+	}
+}
+
+func TestGet_Lossy(t *testing.T) {
+	err := Template("failure").New().Set("n", 300).Set("f", 3.9)
+
+	if v, ok := Get(err, Key[int8]("n")); ok {
+		t.Errorf("expected no value, got %v", v)
+	}
+	if v, ok := Get(err, Key[int]("f")); ok {
+		t.Errorf("expected no value, got %v", v)
+	}
+	if v, ok := Get(err, Key[int16]("n")); !ok || v != 300 {
+		t.Errorf("expected 300, got %v", v)
+	}
+}
@@ -0,0 +1,19 @@
+// Command errorslint checks the conventions of github.com/axkit/errors usage.
+//
+//	go install github.com/axkit/errors/lint/cmd/errorslint@latest
+//	errorslint ./...
+//
+// It can be run by go vet as well:
+//
+//	go vet -vettool=$(which errorslint) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/axkit/errors/lint"
+)
+
+func main() {
+	singlechecker.Main(lint.Analyzer)
+}
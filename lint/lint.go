@@ -0,0 +1,411 @@
+// Package lint provides a go/analysis analyzer enforcing the error-handling
+// conventions of github.com/axkit/errors.
+//
+// The analyzer reports:
+//
+//   - calls to errors.New, which create errors without a code;
+//   - ErrorTemplate values created inside functions instead of
+//     at package level;
+//   - error codes passed to Code(...) or CodeNamespace.Template more than
+//     once, including codes declared by imported packages;
+//   - ToJSON calls in HTTP handlers that include protected data;
+//   - errors returned from exported functions as received from
+//     another package, without being wrapped.
+//
+// It can be run with the errorslint command, directly or as
+// go vet -vettool=$(which errorslint).
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// PkgPath is the import path of the package whose conventions are enforced.
+const PkgPath = "github.com/axkit/errors"
+
+// Analyzer reports violations of the error-handling conventions.
+var Analyzer = &analysis.Analyzer{
+	Name:      "axerrors",
+	Doc:       "check conventions of github.com/axkit/errors usage",
+	URL:       "https://pkg.go.dev/github.com/axkit/errors/lint",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(codesFact)},
+}
+
+// codesFact records the error codes declared by a package,
+// mapped to the position of the declaration.
+type codesFact struct {
+	Codes map[string]string
+}
+
+func (*codesFact) AFact() {}
+
+func (f *codesFact) String() string {
+	codes := make([]string, 0, len(f.Codes))
+	for c := range f.Codes {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return fmt.Sprintf("codes%v", codes)
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	// The package itself defines the conventions.
+	if pass.Pkg.Path() == PkgPath {
+		return nil, nil
+	}
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	known := importedCodes(pass)
+	declared := make(map[string]string)
+
+	filter := []ast.Node{
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.FuncDecl)(nil),
+	}
+	insp.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch x := n.(type) {
+		case *ast.FuncDecl:
+			checkReturns(pass, x)
+		case *ast.CompositeLit:
+			if isNamed(pass.TypesInfo.TypeOf(x), "ErrorTemplate") && inFunc(stack) {
+				pass.Reportf(x.Pos(), "ErrorTemplate should be declared at package level")
+			}
+		case *ast.CallExpr:
+			checkCall(pass, x, stack, known, declared)
+		}
+		return true
+	})
+
+	if len(declared) > 0 {
+		pass.ExportPackageFact(&codesFact{Codes: declared})
+	}
+	return nil, nil
+}
+
+// importedCodes returns the error codes declared by the dependencies
+// of the package being analyzed.
+func importedCodes(pass *analysis.Pass) map[string]string {
+	res := make(map[string]string)
+	for _, pf := range pass.AllPackageFacts() {
+		if f, ok := pf.Fact.(*codesFact); ok {
+			for c, pos := range f.Codes {
+				res[c] = pos
+			}
+		}
+	}
+	return res
+}
+
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, stack []ast.Node, known, declared map[string]string) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != PkgPath {
+		return
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+
+	switch {
+	case recv == nil && fn.Name() == "New":
+		pass.Reportf(call.Pos(), "errors.New creates an error without a code: declare a package-level ErrorTemplate and use its New method")
+
+	case recv == nil && fn.Name() == "Template":
+		if inFunc(stack) {
+			pass.Reportf(call.Pos(), "ErrorTemplate should be declared at package level")
+		}
+
+	case recv != nil && fn.Name() == "Code" && len(call.Args) == 1:
+		checkCode(pass, call.Args[0], known, declared)
+
+	case recv != nil && isNamed(recv.Type(), "CodeNamespace") && fn.Name() == "Template" && len(call.Args) == 2:
+		if inFunc(stack) {
+			pass.Reportf(call.Pos(), "ErrorTemplate should be declared at package level")
+		}
+		checkCode(pass, call.Args[0], known, declared)
+
+	case recv == nil && fn.Name() == "ToJSON":
+		if isHandler(pass, stack) && includesProtected(pass, call) {
+			pass.Reportf(call.Pos(), "ToJSON in an HTTP handler includes protected data: use ClientOutputFormat or drop AddProtected")
+		}
+	}
+}
+
+// checkCode reports a constant code already used by the package
+// or its dependencies.
+func checkCode(pass *analysis.Pass, arg ast.Expr, known, declared map[string]string) {
+	code, ok := stringConst(pass, arg)
+	if !ok {
+		return
+	}
+	if prev, ok := declared[code]; ok {
+		pass.Reportf(arg.Pos(), "duplicate error code %q, first used at %s", code, prev)
+		return
+	}
+	if prev, ok := known[code]; ok {
+		pass.Reportf(arg.Pos(), "duplicate error code %q, first used at %s", code, prev)
+		return
+	}
+	declared[code] = pass.Fset.Position(arg.Pos()).String()
+}
+
+// includesProtected reports whether the ToJSON call has a WithAttributes
+// option with the AddProtected rule.
+func includesProtected(pass *analysis.Pass, call *ast.CallExpr) bool {
+	var obj types.Object
+	for _, imp := range pass.Pkg.Imports() {
+		if imp.Path() == PkgPath {
+			obj = imp.Scope().Lookup("AddProtected")
+		}
+	}
+	c, ok := obj.(*types.Const)
+	if !ok {
+		return false
+	}
+
+	for _, arg := range call.Args[1:] {
+		opt, ok := arg.(*ast.CallExpr)
+		if !ok || len(opt.Args) != 1 {
+			continue
+		}
+		fn, ok := typeutil.Callee(pass.TypesInfo, opt).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != PkgPath || fn.Name() != "WithAttributes" {
+			continue
+		}
+		tv, ok := pass.TypesInfo.Types[opt.Args[0]]
+		if !ok || tv.Value == nil {
+			continue
+		}
+		rule := constant.BinaryOp(tv.Value, token.AND, c.Val())
+		if constant.Sign(rule) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReturns reports errors returned by an exported function as received
+// from a call to another package.
+func checkReturns(pass *analysis.Pass, fd *ast.FuncDecl) {
+	if fd.Body == nil || !fd.Name.IsExported() {
+		return
+	}
+
+	obj, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	results := obj.Type().(*types.Signature).Results()
+	if results.Len() == 0 || !isError(results.At(results.Len()-1).Type()) {
+		return
+	}
+
+	assigned := assignments(pass, fd.Body)
+
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(x.Results) != results.Len() {
+				return true
+			}
+			res := ast.Unparen(x.Results[len(x.Results)-1])
+
+			var callee *types.Func
+			switch r := res.(type) {
+			case *ast.CallExpr:
+				callee, _ = typeutil.Callee(pass.TypesInfo, r).(*types.Func)
+			case *ast.Ident:
+				if v, ok := pass.TypesInfo.Uses[r].(*types.Var); ok {
+					callee = lastAssignment(assigned[v], x.Pos())
+				}
+			}
+
+			if isForeign(pass, callee) {
+				pass.Reportf(res.Pos(), "error returned from %s is not wrapped", calleeName(callee))
+			}
+		}
+		return true
+	})
+}
+
+// assignment records the function whose result was assigned to a variable.
+type assignment struct {
+	pos    token.Pos
+	callee *types.Func
+}
+
+// assignments collects the calls whose results are assigned
+// to local variables.
+func assignments(pass *analysis.Pass, body *ast.BlockStmt) map[*types.Var][]assignment {
+	res := make(map[*types.Var][]assignment)
+
+	record := func(lhs []ast.Expr, rhs []ast.Expr, pos token.Pos) {
+		for i, l := range lhs {
+			id, ok := l.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			v, ok := pass.TypesInfo.ObjectOf(id).(*types.Var)
+			if !ok {
+				continue
+			}
+
+			var r ast.Expr
+			switch {
+			case len(rhs) == 1:
+				r = rhs[0]
+			case i < len(rhs):
+				r = rhs[i]
+			default:
+				continue
+			}
+
+			var callee *types.Func
+			if call, ok := ast.Unparen(r).(*ast.CallExpr); ok {
+				callee, _ = typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			}
+			res[v] = append(res[v], assignment{pos: pos, callee: callee})
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			record(x.Lhs, x.Rhs, x.Pos())
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(x.Names))
+			for i, name := range x.Names {
+				lhs[i] = name
+			}
+			record(lhs, x.Values, x.Pos())
+		}
+		return true
+	})
+	return res
+}
+
+// lastAssignment returns the callee of the last assignment before pos.
+func lastAssignment(as []assignment, pos token.Pos) *types.Func {
+	var res *types.Func
+	for _, a := range as {
+		if a.pos < pos {
+			res = a.callee
+		}
+	}
+	return res
+}
+
+// constructors lists the functions of the standard library that create
+// or wrap errors rather than pass them through.
+var constructors = map[string]bool{
+	"fmt.Errorf":  true,
+	"errors.New":  true,
+	"errors.Join": true,
+}
+
+// isForeign reports whether fn belongs to another package than the one being
+// analyzed and isn't a function of this package, which wraps errors itself.
+func isForeign(pass *analysis.Pass, fn *types.Func) bool {
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+	path := fn.Pkg().Path()
+	if constructors[path+"."+fn.Name()] {
+		return false
+	}
+	return path != pass.Pkg.Path() && path != PkgPath
+}
+
+func calleeName(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if n, ok := t.(*types.Named); ok {
+			return fn.Pkg().Name() + "." + n.Obj().Name() + "." + fn.Name()
+		}
+	}
+	return fn.Pkg().Name() + "." + fn.Name()
+}
+
+// isHandler reports whether the innermost function of the stack
+// has http.ResponseWriter and *http.Request parameters.
+func isHandler(pass *analysis.Pass, stack []ast.Node) bool {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var ft *ast.FuncType
+		switch x := stack[i].(type) {
+		case *ast.FuncDecl:
+			ft = x.Type
+		case *ast.FuncLit:
+			ft = x.Type
+		default:
+			continue
+		}
+
+		var writer, request bool
+		for _, f := range ft.Params.List {
+			t := types.Unalias(pass.TypesInfo.TypeOf(f.Type))
+			writer = writer || isHTTPType(t, "ResponseWriter")
+			if p, ok := t.(*types.Pointer); ok {
+				request = request || isHTTPType(p.Elem(), "Request")
+			}
+		}
+		return writer && request
+	}
+	return false
+}
+
+func isHTTPType(t types.Type, name string) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "net/http" && n.Obj().Name() == name
+}
+
+// isNamed reports whether t is the named type of this package or a pointer to it.
+func isNamed(t types.Type, name string) bool {
+	if p, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == PkgPath && n.Obj().Name() == name
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+func inFunc(stack []ast.Node) bool {
+	for _, n := range stack {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return true
+		}
+	}
+	return false
+}
+
+func stringConst(pass *analysis.Pass, e ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
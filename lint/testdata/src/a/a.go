@@ -0,0 +1,65 @@
+package a // want package:"codes\\[CRM-0001 CRM-0002\\]"
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/axkit/errors"
+)
+
+var (
+	ErrNotFound = errors.Template("not found").Code("CRM-0001")
+	ErrInvalid  = errors.Template("invalid input").Code("CRM-0002")
+	ErrConflict = errors.Template("conflict").Code("CRM-0001") // want `duplicate error code "CRM-0001", first used at .*a.go:12:.*`
+
+	errLiteral = &errors.ErrorTemplate{}
+)
+
+func newErrors() error {
+	_ = errors.Template("local") // want `ErrorTemplate should be declared at package level`
+	_ = &errors.ErrorTemplate{}  // want `ErrorTemplate should be declared at package level`
+	return errors.New("failed")  // want `errors.New creates an error without a code`
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	err := ErrNotFound.New()
+	w.Write(errors.ToJSON(err))
+	w.Write(errors.ToJSON(err, errors.WithAttributes(errors.ClientOutputFormat)))
+	w.Write(errors.ToJSON(err, errors.WithAttributes(errors.AddFields)))
+	w.Write(errors.ToJSON(err, errors.WithAttributes(errors.ServerOutputFormat))) // want `ToJSON in an HTTP handler includes protected data`
+	w.Write(errors.ToJSON(err, errors.WithAttributes(errors.AddProtected)))       // want `ToJSON in an HTTP handler includes protected data`
+}
+
+func logError(err error) []byte {
+	return errors.ToJSON(err, errors.WithAttributes(errors.ServerOutputFormat))
+}
+
+func ReadConfig(name string) ([]byte, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err // want `error returned from os.ReadFile is not wrapped`
+	}
+	return buf, nil
+}
+
+func OpenConfig(name string) error {
+	if _, err := os.Open(name); err != nil {
+		return ErrInvalid.Wrap(err)
+	}
+	return os.Remove(name) // want `error returned from os.Remove is not wrapped`
+}
+
+func CloseConfig(f *os.File) error {
+	err := f.Close()
+	if err != nil {
+		return fmt.Errorf("closing config: %w", err)
+	}
+	err = validate()
+	return err
+}
+
+func validate() error {
+	_, err := os.Stat("config")
+	return err
+}
@@ -0,0 +1,22 @@
+package b // want package:"codes\\[CRM-0100 CRM-0200\\]"
+
+import (
+	"a"
+
+	"github.com/axkit/errors"
+)
+
+var (
+	ErrDuplicate = errors.Template("duplicate").Code("CRM-0002") // want `duplicate error code "CRM-0002", first used at .*a.go:13:.*`
+	ErrUnique    = errors.Template("unique").Code("CRM-0100")
+
+	crm          = errors.Namespace("CRM")
+	ErrNamespace = crm.Template("CRM-0001", "namespaced") // want `duplicate error code "CRM-0001", first used at .*a.go:12:.*`
+	ErrNext      = crm.Next("next")
+
+	_ = a.ErrNotFound
+)
+
+func local() error {
+	return crm.Template("CRM-0200", "local").New() // want `ErrorTemplate should be declared at package level`
+}
@@ -0,0 +1,45 @@
+// Package errors is a stub of github.com/axkit/errors for analyzer tests.
+package errors
+
+type ErrorSerializationRule uint8
+
+const (
+	AddStack ErrorSerializationRule = 1 << iota
+	AddProtected
+	AddFields
+	AddWrappedErrors
+)
+
+const (
+	ServerOutputFormat = AddProtected | AddStack | AddFields | AddWrappedErrors
+	ClientOutputFormat = 0
+)
+
+type Option func()
+
+func WithAttributes(rule ErrorSerializationRule) Option { return nil }
+
+type Error struct{}
+
+func (e *Error) Error() string              { return "" }
+func (e *Error) Code(code string) *Error    { return e }
+func (e *Error) Wrap(err error) *Error      { return e }
+func (e *Error) Set(k string, v any) *Error { return e }
+
+type ErrorTemplate struct{}
+
+func (et *ErrorTemplate) Error() string                   { return "" }
+func (et *ErrorTemplate) Code(code string) *ErrorTemplate { return et }
+func (et *ErrorTemplate) New() *Error                     { return nil }
+func (et *ErrorTemplate) Wrap(err error) *Error           { return nil }
+
+func Template(msg string) *ErrorTemplate      { return nil }
+func New(msg string) *Error                   { return nil }
+func Wrap(err error, msg string) *Error       { return nil }
+func ToJSON(err error, opts ...Option) []byte { return nil }
+
+type CodeNamespace struct{}
+
+func Namespace(prefix string) *CodeNamespace                       { return nil }
+func (ns *CodeNamespace) Template(code, msg string) *ErrorTemplate { return nil }
+func (ns *CodeNamespace) Next(msg string) *ErrorTemplate           { return nil }
@@ -0,0 +1,219 @@
+package errors
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToLogfmt serializes the error to a single logfmt line, like
+//
+//	msg="customer not found" severity=tiny code=CRM-0404 statusCode=404 fields.customer.id=42
+//
+// The line holds the same attributes as ToJSON with the same options.
+// Nested field values are flattened to dotted keys, with indexes for
+// slice elements. Wrapped errors are written as their messages, keyed
+// "wrapped.0", "wrapped.1" and so on, and the stack as a single "stack"
+// value of space-separated frames like "main.main(/app/main.go:10)".
+//
+// Root-level fields follow the scalar members, keyed by their names.
+// Values that can't be encoded are replaced as in ToJSON.
+func ToLogfmt(err error, opts ...Option) []byte {
+	if err == nil {
+		return nil
+	}
+
+	var option ErrorFormattingOptions
+	for _, opt := range opts {
+		opt(&option)
+	}
+
+	serr := Serialize(err, opts...)
+	b, ferr := appendLogfmtError(nil, serr, option.rootLevelCollisions)
+	if ferr != nil && alarmer != nil {
+		alarmer.Alarm(ErrMarshalError.Wrap(ferr))
+	}
+	return b
+}
+
+// appendLogfmtError appends the error as key=value pairs. The root fields
+// follow the scalar members, and keys colliding with other members are
+// handled according to c. The first encoding error is returned along with
+// the complete output.
+func appendLogfmtError(b []byte, serr *SerializedError, c RootLevelCollision) ([]byte, error) {
+	var err, ferr error
+
+	root := placeRootFields(nil, serr.root, func(key string) bool {
+		if defaultOutputSchema.isMember(key) || strings.HasPrefix(key, "wrapped.") {
+			return true
+		}
+		name, ok := strings.CutPrefix(key, "fields.")
+		if !ok {
+			return false
+		}
+		for k := range serr.Fields {
+			if (name == k || strings.HasPrefix(name, k+".")) && !isPromoted(serr.root, k) {
+				return true
+			}
+		}
+		return false
+	}, "fields.", c)
+
+	b = appendLogfmt(b, "msg", serr.Message)
+	if serr.Severity != "" {
+		b = appendLogfmt(b, "severity", serr.Severity)
+	}
+	if serr.Code != "" {
+		b = appendLogfmt(b, "code", serr.Code)
+	}
+	if serr.StatusCode != 0 {
+		b = appendLogfmt(b, "statusCode", strconv.Itoa(serr.StatusCode))
+	}
+
+	for _, f := range root {
+		b, err = appendLogfmtValue(b, f.key, f.value, nil)
+		ferr = cmp.Or(ferr, err)
+	}
+
+	for _, k := range sortedKeys(serr.Fields) {
+		if isPromoted(root, k) {
+			continue
+		}
+		b, err = appendLogfmtValue(b, "fields."+k, serr.Fields[k], nil)
+		ferr = cmp.Or(ferr, err)
+	}
+
+	for i, w := range serr.Wrapped {
+		b = appendLogfmt(b, "wrapped."+strconv.Itoa(i), w.Message)
+	}
+
+	if len(serr.Stack) > 0 {
+		var sb strings.Builder
+		for i, f := range serr.Stack {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(f.Function)
+			sb.WriteByte('(')
+			sb.WriteString(f.File)
+			sb.WriteByte(')')
+		}
+		b = appendLogfmt(b, "stack", sb.String())
+	}
+
+	return b, ferr
+}
+
+// logfmtRef identifies a map or a slice being flattened.
+type logfmtRef struct {
+	ptr uintptr
+	len int
+}
+
+// appendLogfmtValue appends the value under the key, flattening maps
+// and slices to dotted keys. Values other than strings, numbers and
+// booleans are converted through their JSON encoding produced by
+// appendFieldValue; its error is returned along with the output.
+//
+// The maps and slices being flattened are held by seen. A value referring
+// to one of them is replaced by a type marker, as ToJSON does.
+func appendLogfmtValue(b []byte, key string, v any, seen []logfmtRef) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		return appendLogfmt(b, key, x), nil
+	case json.Number:
+		return appendLogfmt(b, key, x.String()), nil
+	case map[string]any:
+		if len(x) == 0 {
+			return appendLogfmt(b, key, "{}"), nil
+		}
+		ref := logfmtRef{ptr: reflect.ValueOf(x).Pointer()}
+		if slices.Contains(seen, ref) {
+			return appendLogfmtCycle(b, key, x)
+		}
+		seen = append(seen, ref)
+
+		var err, ferr error
+		for _, k := range sortedKeys(x) {
+			b, err = appendLogfmtValue(b, key+"."+k, x[k], seen)
+			ferr = cmp.Or(ferr, err)
+		}
+		return b, ferr
+	case []any:
+		if len(x) == 0 {
+			return appendLogfmt(b, key, "[]"), nil
+		}
+		ref := logfmtRef{ptr: reflect.ValueOf(x).Pointer(), len: len(x)}
+		if slices.Contains(seen, ref) {
+			return appendLogfmtCycle(b, key, x)
+		}
+		seen = append(seen, ref)
+
+		var err, ferr error
+		for i, e := range x {
+			b, err = appendLogfmtValue(b, key+"."+strconv.Itoa(i), e, seen)
+			ferr = cmp.Or(ferr, err)
+		}
+		return b, ferr
+	}
+
+	buf, err := appendFieldValue(nil, v)
+	if len(buf) > 0 && buf[0] != '{' && buf[0] != '[' && buf[0] != '"' {
+		// A number, a boolean or null.
+		return appendLogfmt(b, key, string(buf)), err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(buf))
+	d.UseNumber()
+
+	var jv any
+	if derr := d.Decode(&jv); derr != nil {
+		return appendLogfmt(b, key, string(buf)), cmp.Or(err, derr)
+	}
+	b, verr := appendLogfmtValue(b, key, jv, seen)
+	return b, cmp.Or(err, verr)
+}
+
+// appendLogfmtCycle appends the type marker replacing a value
+// that refers to itself.
+func appendLogfmtCycle(b []byte, key string, v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	err := &json.UnsupportedValueError{Value: rv, Str: "encountered a cycle via " + rv.Type().String()}
+	return appendLogfmt(b, key, "("+rv.Type().String()+")"), err
+}
+
+// appendLogfmt appends a key=value pair, quoting the value if needed.
+// Spaces, equal signs, quotes and control characters in the key
+// are replaced by underscores.
+func appendLogfmt(buf []byte, key, value string) []byte {
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			buf = append(buf, '_')
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	buf = append(buf, '=')
+
+	if value == "" || strings.ContainsAny(value, " =\"\\") || !isPrintable(value) {
+		return strconv.AppendQuote(buf, value)
+	}
+	return append(buf, value...)
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r < ' ' || r == utf8.RuneError || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
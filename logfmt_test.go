@@ -0,0 +1,233 @@
+package errors
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+func TestAppendLogfmtError(t *testing.T) {
+	tests := []struct {
+		name      string
+		serr      SerializedError
+		rootLevel []string
+		expected  string
+	}{
+		{
+			name:     "message only",
+			serr:     SerializedError{Message: "failure"},
+			expected: `msg=failure`,
+		},
+		{
+			name:     "empty message",
+			serr:     SerializedError{},
+			expected: `msg=""`,
+		},
+		{
+			name: "all members",
+			serr: SerializedError{
+				Message:    "customer not found",
+				Severity:   "tiny",
+				Code:       "CRM-0404",
+				StatusCode: 404,
+				Fields:     map[string]any{"customerId": 42, "email": "john@example.com"},
+				Wrapped: []SerializedError{
+					{Message: "customer not found", Code: "CRM-0404"},
+					{Message: "EOF"},
+				},
+				Stack: []StackFrame{
+					{Function: "main.main", File: "/app/main.go:10", Line: 10},
+					{Function: "runtime.main", File: "/go/src/runtime/proc.go:283", Line: 283},
+				},
+			},
+			expected: `msg="customer not found" severity=tiny code=CRM-0404 statusCode=404` +
+				` fields.customerId=42 fields.email=john@example.com` +
+				` wrapped.0="customer not found" wrapped.1=EOF` +
+				` stack="main.main(/app/main.go:10) runtime.main(/go/src/runtime/proc.go:283)"`,
+		},
+		{
+			name: "nested values",
+			serr: SerializedError{
+				Message: "failure",
+				Fields: map[string]any{
+					"customer": map[string]any{"id": 42, "tags": []string{"vip", "new"}},
+					"point":    point{X: 1, Y: 2},
+					"ptr":      &point{X: 3},
+					"empty":    map[string]int{},
+					"none":     []any{},
+					"nil":      nil,
+					"big":      uint64(math.MaxUint64),
+					"err":      io.EOF,
+				},
+			},
+			expected: `msg=failure fields.big=18446744073709551615` +
+				` fields.customer.id=42 fields.customer.tags.0=vip fields.customer.tags.1=new` +
+				` fields.empty={} fields.err=EOF fields.nil=null fields.none=[]` +
+				` fields.point.X=1 fields.point.Y=2 fields.ptr.X=3 fields.ptr.Y=0`,
+		},
+		{
+			name: "root level fields",
+			serr: SerializedError{
+				Message: "failure",
+				Code:    "X-1",
+				Fields: map[string]any{
+					"code":      "X-2",
+					"requestId": "req-1",
+					"user":      map[string]any{"id": 7},
+					"stack":     "kept",
+					"other":     true,
+				},
+			},
+			rootLevel: []string{"user", "requestId", "code", "stack", "missing"},
+			expected:  `msg=failure code=X-1 user.id=7 requestId=req-1 fields.code=X-2 fields.stack=kept fields.other=true`,
+		},
+		{
+			name: "quoting",
+			serr: SerializedError{
+				Message: "say \"hi\"\n",
+				Fields:  map[string]any{"a b=c": "x=y", "path": `C:\tmp`},
+			},
+			expected: `msg="say \"hi\"\n" fields.a_b_c="x=y" fields.path="C:\\tmp"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serr := tt.serr
+			serr.root = testRootFields(&serr, tt.rootLevel)
+			got, err := appendLogfmtError(nil, &serr, PrefixCollisions)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToLogfmt(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").Severity(Tiny).
+		Wrap(io.EOF).
+		Set("customerId", 42).
+		Set("requestId", "req-1")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: `msg="customer not found" severity=tiny code=CRM-0404 fields.customerId=42 fields.requestId=req-1`,
+		},
+		{
+			name:     "wrapped errors",
+			opts:     []Option{WithAttributes(AddFields | AddWrappedErrors)},
+			expected: `msg="customer not found" severity=tiny code=CRM-0404 fields.customerId=42 fields.requestId=req-1 wrapped.0=EOF`,
+		},
+		{
+			name:     "root level fields",
+			opts:     []Option{WithRootLevelFields([]string{"requestId"})},
+			expected: `msg="customer not found" severity=tiny code=CRM-0404 requestId=req-1 fields.customerId=42`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ToLogfmt(err, tt.opts...)); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+
+	if ToLogfmt(nil) != nil {
+		t.Errorf("expected nil for nil error")
+	}
+}
+
+func TestToLogfmt_UnsupportedField(t *testing.T) {
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	err := Template("failure").New().
+		Set("ch", make(chan int)).
+		Set("nested", map[string]any{"nan": math.NaN(), "n": 1})
+
+	expected := `msg=failure severity=unknown fields.ch="(chan int)" fields.nested.n=1 fields.nested.nan=NaN`
+	if got := string(ToLogfmt(err)); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if !mock.called || !Is(mock.err, ErrMarshalError) {
+		t.Errorf("expected ErrMarshalError alarm, got %v", mock.err)
+	}
+}
+
+func TestToLogfmt_CyclicField(t *testing.T) {
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	self := map[string]any{}
+	self["self"] = self
+
+	twice := map[string]any{"n": 1}
+	twice["a"] = twice
+	twice["b"] = twice
+
+	list := []any{1, nil}
+	list[1] = list
+
+	shared := map[string]any{"n": 1}
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+		failed   bool
+	}{
+		{"self reference", self, `fields.v.self="(map[string]interface {})"`, true},
+		{"two self references", twice, `fields.v.a="(map[string]interface {})" fields.v.b="(map[string]interface {})" fields.v.n=1`, true},
+		{"slice", list, `fields.v.0=1 fields.v.1="([]interface {})"`, true},
+		{"shared without a cycle", map[string]any{"a": shared, "b": shared}, `fields.v.a.n=1 fields.v.b.n=1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.called, mock.err = false, nil
+			err := Template("failure").New().Set("v", tt.value)
+
+			expected := `msg=failure severity=unknown ` + tt.expected
+			if got := string(ToLogfmt(err)); got != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+			if mock.called != tt.failed {
+				t.Errorf("expected alarm %v, got %v", tt.failed, mock.err)
+			}
+		})
+	}
+}
+
+func TestAppendLogfmt(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"k", "plain", "k=plain"},
+		{"k", "", `k=""`},
+		{"k", "two words", `k="two words"`},
+		{"k", "a=b", `k="a=b"`},
+		{"k", `say "hi"`, `k="say \"hi\""`},
+		{"k", "line\nbreak", `k="line\nbreak"`},
+		{"a b", "v", "a_b=v"},
+		{"a=\"b\"\n", "v", "a__b__=v"},
+	}
+
+	for _, tt := range tests {
+		if got := string(appendLogfmt(nil, tt.key, tt.value)); got != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrUnresolvedPlaceholders is returned by ValidateMessage.
+var ErrUnresolvedPlaceholders = Template("message refers to fields never set").Severity(Tiny)
+
+// interpolate replaces placeholders like {customerId} in the message with
+// values returned by lookup and unescapes doubled braces. Placeholders
+// unknown to lookup are left as is.
+func interpolate(msg string, lookup func(key string) (any, bool)) string {
+	if !strings.ContainsAny(msg, "{}") {
+		return msg
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(msg))
+
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		switch {
+		case c == '{' && i+1 < len(msg) && msg[i+1] == '{':
+			sb.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(msg) && msg[i+1] == '}':
+			sb.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(msg[i+1:], '}')
+			if end < 0 {
+				sb.WriteString(msg[i:])
+				return sb.String()
+			}
+			key := msg[i+1 : i+1+end]
+			if v, ok := lookup(key); ok {
+				fmt.Fprint(&sb, v)
+			} else {
+				sb.WriteString(msg[i : i+end+2])
+			}
+			i += end + 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// braceEscaper escapes braces in text that must not be interpolated.
+var braceEscaper = strings.NewReplacer("{", "{{", "}", "}}")
+
+// rawMessage returns the message as it was given: doubled braces
+// are unescaped and placeholders are left as is.
+func rawMessage(msg string) string {
+	return interpolate(msg, func(string) (any, bool) { return nil, false })
+}
+
+// placeholders returns the names of placeholders in the message.
+func placeholders(msg string) []string {
+	var res []string
+	interpolate(msg, func(key string) (any, bool) {
+		res = append(res, key)
+		return nil, false
+	})
+	return res
+}
+
+// messageLookup returns a lookup function resolving placeholders from the
+// fields of the error chain with sensitive values redacted.
+func messageLookup(err error, option *ErrorFormattingOptions) func(string) (any, bool) {
+	return func(key string) (any, bool) {
+		v, ok := Field(err, key)
+		if !ok {
+			return nil, false
+		}
+		return redactValue(err, key, v, option)
+	}
+}
+
+// formatMessage returns the message with placeholders replaced.
+func formatMessage(err error, msg string, option *ErrorFormattingOptions) string {
+	if !strings.ContainsAny(msg, "{}") {
+		return msg
+	}
+	return interpolate(msg, messageLookup(err, option))
+}
+
+// ValidateMessage returns an error wrapping ErrUnresolvedPlaceholders if
+// messages in the error chain refer to fields that are not set.
+// The names of such fields are listed in the "placeholders" field.
+// It's intended for tests:
+//
+//	err := ErrCustomerNotFound.New()
+//	if verr := errors.ValidateMessage(err); verr != nil {
+//		t.Error(verr)
+//	}
+func ValidateMessage(err error) error {
+	var missing []string
+	for l := range chainLevels(err) {
+		for _, key := range placeholders(l.metadata.message) {
+			if _, ok := Field(err, key); !ok {
+				missing = append(missing, key)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return ErrUnresolvedPlaceholders.Wrap(New(strings.Join(missing, ", "))).
+		Set("placeholders", missing)
+}
@@ -0,0 +1,130 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	fields := map[string]any{"customerId": 42, "name": "{john}"}
+	lookup := func(key string) (any, bool) {
+		v, ok := fields[key]
+		return v, ok
+	}
+
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{"customer not found", "customer not found"},
+		{"customer {customerId} not found", "customer 42 not found"},
+		{"{customerId}", "42"},
+		{"customer {name}", "customer {john}"},
+		{"unknown {orderId} kept", "unknown {orderId} kept"},
+		{"escaped {{customerId}}", "escaped {customerId}"},
+		{"escaped {{{customerId}}}", "escaped {42}"},
+		{"unterminated {customerId", "unterminated {customerId"},
+		{"stray } brace", "stray } brace"},
+		{"empty {}", "empty {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			if got := interpolate(tt.msg, lookup); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMessagePlaceholders(t *testing.T) {
+	ErrCustomerNotFound := Template("customer {customerId} not found").
+		Code("CRM-0404").
+		Sensitive("email", RedactDrop)
+	ErrOrderFailed := Template("order {orderId} failed for {email}")
+
+	err := ErrOrderFailed.Wrap(ErrCustomerNotFound.Wrap(io.EOF).Set("customerId", 42)).
+		Set("orderId", "A-1").
+		Set("email", "john@example.com")
+
+	if expected := "order A-1 failed for {email}: customer 42 not found: EOF"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+
+	var res SerializedError
+	_ = json.Unmarshal(ToJSON(err, WithAttributes(AddWrappedErrors|AddSensitive)), &res)
+	if res.Message != "order A-1 failed for john@example.com" {
+		t.Errorf("unexpected message %q", res.Message)
+	}
+	if len(res.Wrapped) != 2 || res.Wrapped[0].Message != "customer 42 not found" {
+		t.Errorf("unexpected wrapped errors %v", res.Wrapped)
+	}
+
+	tmpl := Template("limit {limit} exceeded").Set("limit", 10)
+	if tmpl.Error() != "limit 10 exceeded" {
+		t.Errorf("unexpected template message %q", tmpl.Error())
+	}
+}
+
+func TestMessagePlaceholders_AdHocMessages(t *testing.T) {
+	ErrCustomerNotFound := Template("customer {customerId} not found")
+
+	tests := []struct {
+		name     string
+		err      *Error
+		expected string
+	}{
+		{"Wrap", Wrap(io.EOF, "reading {token}"), "reading {token}: EOF"},
+		{"Wrap of Error", Wrap(ErrCustomerNotFound.New(), "lookup of {customerId} failed"), "lookup of {customerId} failed"},
+		{"Msg", ErrCustomerNotFound.New().Msg("customer {customerId} is gone"), "customer {customerId} is gone"},
+		{"template", ErrCustomerNotFound.New(), "customer 42 not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err.Set("token", "s3cr3t").Set("customerId", 42)
+			if got := err.Error(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if got := Serialize(err).Message; got != strings.SplitN(tt.expected, ": ", 2)[0] {
+				t.Errorf("expected serialized %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMessagePlaceholders_Localized(t *testing.T) {
+	c := NewCatalog("en").Add("de", "CRM-0404", "Kunde {customerId} nicht gefunden")
+	err := Template("customer {customerId} not found").Code("CRM-0404").New().Set("customerId", 42)
+
+	if se := Serialize(err, WithLocale(c, "de")); se.Message != "Kunde 42 nicht gefunden" {
+		t.Errorf("unexpected message %q", se.Message)
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	ErrCustomerNotFound := Template("customer {customerId} not found in {region}")
+
+	if err := ValidateMessage(ErrCustomerNotFound.New().Set("customerId", 1).Set("region", "eu")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	inner := ErrCustomerNotFound.New().Set("customerId", 1)
+	err := ValidateMessage(Template("lookup {{failed}} for {tenant}").Wrap(inner))
+	if !Is(err, ErrUnresolvedPlaceholders) {
+		t.Fatalf("expected ErrUnresolvedPlaceholders, got %v", err)
+	}
+	if expected := "message refers to fields never set: tenant, region"; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+	if v, _ := Field(err, "placeholders"); !reflect.DeepEqual(v, []string{"tenant", "region"}) {
+		t.Errorf("unexpected placeholders %v", v)
+	}
+
+	if err := ValidateMessage(io.EOF); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
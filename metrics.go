@@ -0,0 +1,153 @@
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// MetricEvent identifies the moment an error is counted.
+type MetricEvent uint8
+
+const (
+	// MetricErrorCreated is recorded once for every Error created by
+	// ErrorTemplate.New, or by a wrapping function wrapping an error other
+	// than Error. Wrapping an Error again doesn't count a new error.
+	//
+	// The error is counted when it's first wrapped, serialized or its message
+	// is produced by Error, which fmt and slog use as well. So the labels
+	// include the attributes set after the error was created, like in
+	// errors.Template("failed").New().Code("C"). An error dropped without
+	// any of these isn't counted.
+	MetricErrorCreated MetricEvent = iota + 1
+
+	// MetricErrorSerialized is recorded when an error is passed to Serialize or ToJSON.
+	MetricErrorSerialized
+)
+
+// String returns metric event string representation.
+func (me MetricEvent) String() string {
+	switch me {
+	case MetricErrorCreated:
+		return "created"
+	case MetricErrorSerialized:
+		return "serialized"
+	}
+	return "unknown"
+}
+
+// MetricLabels holds the attributes an error is counted by.
+type MetricLabels struct {
+	Code       string
+	Severity   SeverityLevel
+	StatusCode int
+}
+
+// MetricsRecorder is an interface wrapping a single method RecordError.
+//
+// RecordError is invocated automatically when an error is created or
+// serialized, if metrics recorder is set.
+type MetricsRecorder interface {
+	RecordError(event MetricEvent, labels MetricLabels)
+}
+
+var metricsRecorder MetricsRecorder
+
+// SetMetricsRecorder sets MetricsRecorder implementation to be used for counting errors.
+// Passing nil disables counting.
+func SetMetricsRecorder(m MetricsRecorder) {
+	metricsRecorder = m
+}
+
+// markCreated marks the new error as uncounted if it's created rather than
+// copied from another Error and wraps no Error, and counts the wrapped
+// errors still uncounted. A copy keeps the state of the original.
+func markCreated(e *Error, created bool) {
+	if metricsRecorder == nil {
+		return
+	}
+	for x := range chainErrors(e.err) {
+		created = false
+		countCreated(x)
+	}
+	if created {
+		atomic.StoreUint32(&e.uncounted, 1)
+	}
+}
+
+// countCreated records MetricErrorCreated for the error, unless it was
+// already counted.
+func countCreated(e *Error) {
+	if metricsRecorder != nil && atomic.CompareAndSwapUint32(&e.uncounted, 1, 0) {
+		recordMetric(MetricErrorCreated, e)
+	}
+}
+
+func recordMetric(event MetricEvent, err error) {
+	var md metadata
+	switch x := err.(type) {
+	case *Error:
+		md = x.metadata
+	case *ErrorTemplate:
+		md = x.metadata
+	default:
+		return
+	}
+
+	metricsRecorder.RecordError(event, MetricLabels{
+		Code:       md.code,
+		Severity:   md.severity,
+		StatusCode: md.statusCode,
+	})
+}
+
+// MemoryMetrics is an in-memory MetricsRecorder. It's safe for concurrent use.
+type MemoryMetrics struct {
+	mu     sync.Mutex
+	counts map[memoryMetricsKey]uint64
+}
+
+type memoryMetricsKey struct {
+	event  MetricEvent
+	labels MetricLabels
+}
+
+// NewMemoryMetrics returns a new in-memory metrics recorder.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{counts: make(map[memoryMetricsKey]uint64)}
+}
+
+// RecordError implements MetricsRecorder interface.
+func (m *MemoryMetrics) RecordError(event MetricEvent, labels MetricLabels) {
+	m.mu.Lock()
+	m.counts[memoryMetricsKey{event, labels}]++
+	m.mu.Unlock()
+}
+
+// Count returns how many times the event was recorded with the labels.
+func (m *MemoryMetrics) Count(event MetricEvent, labels MetricLabels) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[memoryMetricsKey{event, labels}]
+}
+
+// CountByCode returns how many times the event was recorded for the error code,
+// regardless of other labels.
+func (m *MemoryMetrics) CountByCode(event MetricEvent, code string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res uint64
+	for k, v := range m.counts {
+		if k.event == event && k.labels.Code == code {
+			res += v
+		}
+	}
+	return res
+}
+
+// Reset clears all counters.
+func (m *MemoryMetrics) Reset() {
+	m.mu.Lock()
+	clear(m.counts)
+	m.mu.Unlock()
+}
@@ -0,0 +1,108 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestMetricEvent_String(t *testing.T) {
+	tests := []struct {
+		event    MetricEvent
+		expected string
+	}{
+		{MetricErrorCreated, "created"},
+		{MetricErrorSerialized, "serialized"},
+		{MetricEvent(0), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.event.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestSetMetricsRecorder(t *testing.T) {
+	m := NewMemoryMetrics()
+	SetMetricsRecorder(m)
+	t.Cleanup(func() { SetMetricsRecorder(nil) })
+
+	ErrInvalidInput := Template("invalid input").Code("CRM-0901").StatusCode(400).Severity(Tiny)
+	ErrHandler := Template("handler failed").Code("CRM-0500")
+	labels := MetricLabels{Code: "CRM-0901", Severity: Tiny, StatusCode: 400}
+
+	err := ErrInvalidInput.New()
+	if n := m.Count(MetricErrorCreated, labels); n != 0 {
+		t.Errorf("expected the error to be counted when wrapped, got %d", n)
+	}
+
+	chain := Wrap(ErrHandler.Wrap(fmt.Errorf("validating: %w", err)), "request failed")
+	_ = ToJSON(chain)
+	_ = Serialize(chain)
+
+	if n := m.Count(MetricErrorCreated, labels); n != 1 {
+		t.Errorf("expected 1 created error, got %d", n)
+	}
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0500"); n != 0 {
+		t.Errorf("expected wrapping errors not to be counted, got %d", n)
+	}
+	if n := m.CountByCode(MetricErrorSerialized, "CRM-0500"); n != 2 {
+		t.Errorf("expected 2 serialized errors, got %d", n)
+	}
+
+	// Labels set after the error is created are counted.
+	_ = ToJSON(Wrap(io.EOF, "reading failed").Code("IO-0001"))
+	if n := m.CountByCode(MetricErrorCreated, "IO-0001"); n != 1 {
+		t.Errorf("expected 1 created error by code, got %d", n)
+	}
+	if n := m.Count(MetricErrorCreated, MetricLabels{}); n != 0 {
+		t.Errorf("expected no created error without labels, got %d", n)
+	}
+
+	// Each error wrapped by Errorf is counted.
+	_ = ErrHandler.Wrap(Errorf("%w, %w", ErrInvalidInput.New(), ErrInvalidInput.Wrap(io.EOF)))
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0901"); n != 3 {
+		t.Errorf("expected 3 created errors by code, got %d", n)
+	}
+
+	_ = ToJSON(io.EOF)
+
+	m.Reset()
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0901"); n != 0 {
+		t.Errorf("expected counters to be reset, got %d", n)
+	}
+}
+
+func TestSetMetricsRecorder_CountedOnce(t *testing.T) {
+	m := NewMemoryMetrics()
+	SetMetricsRecorder(m)
+	t.Cleanup(func() { SetMetricsRecorder(nil) })
+
+	ErrNotFound := Template("not found").Code("CRM-0404")
+
+	// Copies made by Wrap take over the counted state.
+	err := ErrNotFound.New()
+	_ = ToJSON(err)
+	_ = ToJSON(Wrap(Wrap(err, "again"), "and again"))
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0404"); n != 1 {
+		t.Errorf("expected 1 created error, got %d", n)
+	}
+
+	// An uncounted error is counted once by its copy.
+	m.Reset()
+	_ = ToJSON(Wrap(ErrNotFound.New(), "again"))
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0404"); n != 1 {
+		t.Errorf("expected 1 created error, got %d", n)
+	}
+
+	// Errors only logged or formatted are counted.
+	m.Reset()
+	slog.New(slog.NewTextHandler(io.Discard, nil)).Error("failed", "err", ErrNotFound.New())
+	_ = ErrNotFound.New().Error()
+	_ = fmt.Sprintf("%v", ErrNotFound.New())
+	if n := m.CountByCode(MetricErrorCreated, "CRM-0404"); n != 3 {
+		t.Errorf("expected 3 created errors, got %d", n)
+	}
+}
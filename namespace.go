@@ -0,0 +1,219 @@
+package errors
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCodePattern is the format of codes checked by namespaces created
+// without WithCodePattern: an uppercase prefix, a dash and four digits,
+// like "CRM-0901".
+var DefaultCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*-[0-9]{4}$`)
+
+// DefaultCodeFormat is the format of codes numbered by namespaces created
+// without WithCodeFormat. It's applied to the prefix and the number.
+var DefaultCodeFormat = "%s-%04d"
+
+// CodeNamespace owns a code prefix. Templates created by the namespace get
+// codes starting with the prefix, either given explicitly and checked
+// against the namespace's pattern, or numbered automatically.
+//
+//	var crm = errors.Namespace("CRM")
+//
+//	var (
+//		ErrCustomerNotFound = crm.Template("CRM-0404", "customer not found")
+//		ErrInvalidInput     = crm.Template("CRM-0901", "invalid input")
+//		ErrDuplicateEmail   = crm.Next("duplicate email") // CRM-0001
+//	)
+//
+// Codes are checked when templates are created, usually during package
+// initialization, so a misconfigured code fails on program start.
+type CodeNamespace struct {
+	prefix  string
+	pattern *regexp.Regexp
+	format  string
+
+	mu        sync.Mutex
+	next      int
+	templates map[string]*ErrorTemplate
+}
+
+// NamespaceOption configures a CodeNamespace.
+type NamespaceOption func(*CodeNamespace)
+
+// WithCodePattern sets the pattern the namespace's codes must match.
+func WithCodePattern(re *regexp.Regexp) NamespaceOption {
+	return func(ns *CodeNamespace) {
+		ns.pattern = re
+	}
+}
+
+// WithCodeFormat sets the format of automatically numbered codes.
+// The format is applied to the prefix and the number: "%s-%04d".
+func WithCodeFormat(format string) NamespaceOption {
+	return func(ns *CodeNamespace) {
+		ns.format = format
+	}
+}
+
+// WithFirstNumber sets the first number used by Next. It's 1 by default.
+func WithFirstNumber(n int) NamespaceOption {
+	return func(ns *CodeNamespace) {
+		ns.next = n
+	}
+}
+
+var (
+	namespacesMu sync.RWMutex
+	namespaces   = map[string]*CodeNamespace{}
+)
+
+// Namespace registers a code prefix and returns the namespace owning it.
+// It panics if the prefix is empty, contains a dash or is already registered,
+// so two teams can't claim the same prefix.
+func Namespace(prefix string, opts ...NamespaceOption) *CodeNamespace {
+	if prefix == "" || strings.Contains(prefix, "-") {
+		panic("axkit/errors: invalid code namespace " + strconv.Quote(prefix))
+	}
+
+	ns := &CodeNamespace{
+		prefix:    prefix,
+		pattern:   DefaultCodePattern,
+		format:    DefaultCodeFormat,
+		next:      1,
+		templates: make(map[string]*ErrorTemplate),
+	}
+	for _, opt := range opts {
+		opt(ns)
+	}
+
+	namespacesMu.Lock()
+	defer namespacesMu.Unlock()
+
+	if _, ok := namespaces[prefix]; ok {
+		panic("axkit/errors: code namespace " + strconv.Quote(prefix) + " is already registered")
+	}
+	namespaces[prefix] = ns
+	return ns
+}
+
+// Prefix returns the namespace's code prefix.
+func (ns *CodeNamespace) Prefix() string {
+	return ns.prefix
+}
+
+// Template returns a new ErrorTemplate with the given code and message.
+// It panics if the code doesn't start with the namespace's prefix followed
+// by a dash, doesn't match the namespace's pattern or is already used.
+func (ns *CodeNamespace) Template(code, msg string) *ErrorTemplate {
+	if err := ns.check(code); err != "" {
+		panic("axkit/errors: " + err)
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if _, ok := ns.templates[code]; ok {
+		panic("axkit/errors: error code " + strconv.Quote(code) + " is already registered")
+	}
+
+	res := Template(msg).Code(code)
+	ns.templates[code] = res
+	return res
+}
+
+// Next returns a new ErrorTemplate with the next free code of the namespace.
+// Codes are produced by the namespace's format from the prefix and a number
+// starting at 1. It panics if the code doesn't match the namespace's pattern.
+func (ns *CodeNamespace) Next(msg string) *ErrorTemplate {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	var code string
+	for {
+		code = fmt.Sprintf(ns.format, ns.prefix, ns.next)
+		ns.next++
+		if _, ok := ns.templates[code]; !ok {
+			break
+		}
+	}
+
+	if err := ns.check(code); err != "" {
+		panic("axkit/errors: " + err)
+	}
+
+	res := Template(msg).Code(code)
+	ns.templates[code] = res
+	return res
+}
+
+// check returns the description of the code's violation of the namespace's
+// rules, or an empty string.
+func (ns *CodeNamespace) check(code string) string {
+	if !strings.HasPrefix(code, ns.prefix+"-") {
+		return "error code " + strconv.Quote(code) + " is outside of namespace " + strconv.Quote(ns.prefix)
+	}
+	if ns.pattern != nil && !ns.pattern.MatchString(code) {
+		return "error code " + strconv.Quote(code) + " doesn't match " + ns.pattern.String()
+	}
+	return ""
+}
+
+// Lookup returns the namespace's template with the given code.
+func (ns *CodeNamespace) Lookup(code string) (*ErrorTemplate, bool) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	et, ok := ns.templates[code]
+	return et, ok
+}
+
+// Templates returns the namespace's templates ordered by code.
+func (ns *CodeNamespace) Templates() []*ErrorTemplate {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	return slices.SortedFunc(maps.Values(ns.templates), func(a, b *ErrorTemplate) int {
+		return cmp.Compare(a.code, b.code)
+	})
+}
+
+// LookupNamespace returns the namespace registered with the prefix.
+func LookupNamespace(prefix string) (*CodeNamespace, bool) {
+	namespacesMu.RLock()
+	defer namespacesMu.RUnlock()
+
+	ns, ok := namespaces[prefix]
+	return ns, ok
+}
+
+// Namespaces returns the registered namespaces ordered by prefix.
+func Namespaces() []*CodeNamespace {
+	namespacesMu.RLock()
+	defer namespacesMu.RUnlock()
+
+	return slices.SortedFunc(maps.Values(namespaces), func(a, b *CodeNamespace) int {
+		return cmp.Compare(a.prefix, b.prefix)
+	})
+}
+
+// TemplateByCode returns the template with the given code
+// from the namespace owning the code's prefix.
+func TemplateByCode(code string) (*ErrorTemplate, bool) {
+	prefix, _, ok := strings.Cut(code, "-")
+	if !ok {
+		return nil, false
+	}
+
+	ns, ok := LookupNamespace(prefix)
+	if !ok {
+		return nil, false
+	}
+	return ns.Lookup(code)
+}
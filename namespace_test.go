@@ -0,0 +1,149 @@
+package errors
+
+import (
+	"regexp"
+	"testing"
+)
+
+func newTestNamespace(t *testing.T, prefix string, opts ...NamespaceOption) *CodeNamespace {
+	t.Helper()
+
+	ns := Namespace(prefix, opts...)
+	t.Cleanup(func() {
+		namespacesMu.Lock()
+		delete(namespaces, prefix)
+		namespacesMu.Unlock()
+	})
+	return ns
+}
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: expected panic", name)
+		}
+	}()
+	fn()
+}
+
+func TestNamespace(t *testing.T) {
+	crm := newTestNamespace(t, "CRM")
+
+	if crm.Prefix() != "CRM" {
+		t.Errorf("expected prefix %q, got %q", "CRM", crm.Prefix())
+	}
+
+	if ns, ok := LookupNamespace("CRM"); !ok || ns != crm {
+		t.Errorf("expected namespace to be registered")
+	}
+
+	expectPanic(t, "duplicate prefix", func() { Namespace("CRM") })
+	expectPanic(t, "empty prefix", func() { Namespace("") })
+	expectPanic(t, "prefix with dash", func() { Namespace("CRM-A") })
+}
+
+func TestCodeNamespace_Template(t *testing.T) {
+	crm := newTestNamespace(t, "CRM")
+
+	et := crm.Template("CRM-0901", "invalid input")
+	if et.code != "CRM-0901" || et.message != "invalid input" {
+		t.Errorf("unexpected template %q %q", et.code, et.message)
+	}
+
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"duplicate code", "CRM-0901"},
+		{"other namespace", "SRV-0253"},
+		{"prefix only", "CRMX-0001"},
+		{"pattern mismatch", "CRM-1"},
+	}
+
+	for _, tt := range tests {
+		expectPanic(t, tt.name, func() { crm.Template(tt.code, "failure") })
+	}
+}
+
+func TestCodeNamespace_Next(t *testing.T) {
+	srv := newTestNamespace(t, "SRV")
+
+	srv.Template("SRV-0002", "taken")
+
+	codes := []string{
+		srv.Next("first").code,
+		srv.Next("second").code,
+		srv.Next("third").code,
+	}
+	expected := []string{"SRV-0001", "SRV-0003", "SRV-0004"}
+
+	for i := range expected {
+		if codes[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], codes[i])
+		}
+	}
+}
+
+func TestCodeNamespace_Options(t *testing.T) {
+	pay := newTestNamespace(t, "PAY",
+		WithCodePattern(regexp.MustCompile(`^PAY-[0-9]{3}$`)),
+		WithCodeFormat("%s-%03d"),
+		WithFirstNumber(100),
+	)
+
+	if code := pay.Next("declined").code; code != "PAY-100" {
+		t.Errorf("expected %q, got %q", "PAY-100", code)
+	}
+
+	pay.Template("PAY-200", "expired")
+	expectPanic(t, "default pattern", func() { pay.Template("PAY-0300", "failure") })
+
+	bad := newTestNamespace(t, "BAD", WithCodeFormat("%s_%d"))
+	expectPanic(t, "format mismatch", func() { bad.Next("failure") })
+}
+
+func TestCodeNamespace_Query(t *testing.T) {
+	crm := newTestNamespace(t, "CRM")
+	srv := newTestNamespace(t, "SRV")
+
+	notFound := crm.Template("CRM-0404", "customer not found")
+	invalid := crm.Template("CRM-0100", "invalid input")
+	unavailable := srv.Next("service unavailable")
+
+	templates := crm.Templates()
+	if len(templates) != 2 || templates[0] != invalid || templates[1] != notFound {
+		t.Errorf("expected templates ordered by code, got %v", templates)
+	}
+
+	if et, ok := crm.Lookup("CRM-0404"); !ok || et != notFound {
+		t.Errorf("expected to find CRM-0404")
+	}
+
+	tests := []struct {
+		code     string
+		expected *ErrorTemplate
+	}{
+		{"CRM-0404", notFound},
+		{"SRV-0001", unavailable},
+		{"CRM-0999", nil},
+		{"ABC-0001", nil},
+		{"plain", nil},
+	}
+
+	for _, tt := range tests {
+		et, ok := TemplateByCode(tt.code)
+		if et != tt.expected || ok != (tt.expected != nil) {
+			t.Errorf("%s: expected %v, got %v (found: %v)", tt.code, tt.expected, et, ok)
+		}
+	}
+
+	var prefixes []string
+	for _, ns := range Namespaces() {
+		prefixes = append(prefixes, ns.Prefix())
+	}
+	if len(prefixes) != 2 || prefixes[0] != "CRM" || prefixes[1] != "SRV" {
+		t.Errorf("expected [CRM SRV], got %v", prefixes)
+	}
+}
@@ -0,0 +1,106 @@
+package errors
+
+import "sync/atomic"
+
+// Event identifies an error lifecycle event passed to observers.
+type Event uint8
+
+const (
+	// EventTemplate is emitted when an ErrorTemplate is created by Template.
+	// The observed error is the *ErrorTemplate.
+	EventTemplate Event = iota + 1
+
+	// EventNew is emitted when an Error is instantiated by ErrorTemplate.New.
+	EventNew
+
+	// EventWrap is emitted when an Error is created by Wrap, ErrorTemplate.Wrap
+	// or Error.Wrap. The observed error is the new wrapping *Error.
+	EventWrap
+
+	// EventSerialize is emitted when an error is passed to Serialize or ToJSON.
+	EventSerialize
+)
+
+// String returns event string representation.
+func (ev Event) String() string {
+	switch ev {
+	case EventTemplate:
+		return "template"
+	case EventNew:
+		return "new"
+	case EventWrap:
+		return "wrap"
+	case EventSerialize:
+		return "serialize"
+	}
+	return "unknown"
+}
+
+// Observer is an interface wrapping a single method Observe.
+//
+// Observe is invocated synchronously on every error lifecycle event,
+// if observers are added. Implementations must be safe for concurrent use
+// and should return quickly.
+type Observer interface {
+	Observe(ev Event, err error)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as observers.
+type ObserverFunc func(ev Event, err error)
+
+// Observe calls f(ev, err).
+func (f ObserverFunc) Observe(ev Event, err error) {
+	f(ev, err)
+}
+
+var observers atomic.Pointer[[]Observer]
+
+// AddObserver adds the observer to be notified about error lifecycle events.
+// Observers are notified in the order they were added.
+func AddObserver(o Observer) {
+	for {
+		old := observers.Load()
+
+		var next []Observer
+		if old != nil {
+			next = append(next, *old...)
+		}
+		next = append(next, o)
+
+		if observers.CompareAndSwap(old, &next) {
+			return
+		}
+	}
+}
+
+// ResetObservers removes all observers.
+func ResetObservers() {
+	observers.Store(nil)
+}
+
+// notify passes the event to the metrics recorder and observers.
+// It costs a few checks when nothing is set.
+func notify(ev Event, err error) {
+	if ev == EventSerialize {
+		if check := fieldChecker.Load(); check != nil {
+			(*check)(err)
+		}
+	}
+
+	if metricsRecorder != nil {
+		if ev == EventSerialize {
+			for e := range chainErrors(err) {
+				countCreated(e)
+			}
+			recordMetric(MetricErrorSerialized, err)
+		}
+	}
+
+	p := observers.Load()
+	if p == nil {
+		return
+	}
+	for _, o := range *p {
+		o.Observe(ev, err)
+	}
+}
@@ -0,0 +1,90 @@
+package errors
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+type observedEvent struct {
+	ev  Event
+	err error
+}
+
+func TestEvent_String(t *testing.T) {
+	tests := []struct {
+		ev       Event
+		expected string
+	}{
+		{EventTemplate, "template"},
+		{EventNew, "new"},
+		{EventWrap, "wrap"},
+		{EventSerialize, "serialize"},
+		{Event(0), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ev.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestAddObserver(t *testing.T) {
+	var events []observedEvent
+	var order []int
+
+	AddObserver(ObserverFunc(func(ev Event, err error) {
+		events = append(events, observedEvent{ev, err})
+		order = append(order, 1)
+	}))
+	AddObserver(ObserverFunc(func(ev Event, err error) {
+		order = append(order, 2)
+	}))
+	t.Cleanup(ResetObservers)
+
+	tmpl := Template("failure")
+	e1 := tmpl.New()
+	e2 := tmpl.Wrap(io.EOF)
+	e3 := e1.Wrap(io.EOF)
+	e4 := Wrap(io.EOF, "reading failed")
+	_ = ToJSON(e4)
+	_ = Serialize(e4)
+
+	expected := []observedEvent{
+		{EventTemplate, tmpl},
+		{EventNew, e1},
+		{EventWrap, e2},
+		{EventWrap, e3},
+		{EventWrap, e4},
+		{EventSerialize, e4},
+		{EventSerialize, e4},
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %v, got %v", expected, events)
+	}
+
+	if len(order) != 2*len(expected) || order[0] != 1 || order[1] != 2 {
+		t.Errorf("expected observers to be called in order, got %v", order)
+	}
+}
+
+func TestResetObservers(t *testing.T) {
+	var called bool
+	AddObserver(ObserverFunc(func(Event, error) { called = true }))
+	ResetObservers()
+
+	_ = Template("failure").New()
+	if called {
+		t.Error("expected observer not to be called after reset")
+	}
+}
+
+func BenchmarkErrorTemplate_New(b *testing.B) {
+	tmpl := Template("failure").Code("E-0001")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = tmpl.New()
+	}
+}
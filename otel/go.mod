@@ -0,0 +1,24 @@
+module github.com/axkit/errors/otel
+
+go 1.23.0
+
+require (
+	github.com/axkit/errors v0.0.0-20261018235932-ad564d45714d
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	google.golang.org/protobuf v1.36.8 // indirect
+)
+
+// The replace directive is used for local development only;
+// consumers resolve the required version above.
+replace github.com/axkit/errors => ../
@@ -0,0 +1,170 @@
+// Package otel records errors created by github.com/axkit/errors onto
+// OpenTelemetry spans.
+//
+// The error's code, severity, status code and fields are added as span event
+// attributes, the stack captured by the error is written as the exception
+// stacktrace, and the span status is set according to the error severity.
+// The trace and span identifiers are copied back into the error's fields,
+// so they appear in the log entry written at the top of the call stack.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/axkit/errors"
+)
+
+// Attribute keys used for the span event.
+const (
+	CodeKey       = attribute.Key("error.code")
+	SeverityKey   = attribute.Key("error.severity")
+	StatusCodeKey = attribute.Key("error.statusCode")
+
+	// FieldKeyPrefix is prepended to the names of error fields.
+	FieldKeyPrefix = "error.field."
+
+	// StacktraceKey follows OpenTelemetry semantic conventions for exceptions.
+	StacktraceKey = attribute.Key("exception.stacktrace")
+)
+
+// Field names used to copy span context back into the error.
+const (
+	TraceIDField = "traceId"
+	SpanIDField  = "spanId"
+)
+
+type options struct {
+	status map[string]codes.Code
+}
+
+// Option configures RecordError.
+type Option func(*options)
+
+// WithSeverityStatus overrides the span status set for errors of the severity level.
+func WithSeverityStatus(level errors.SeverityLevel, code codes.Code) Option {
+	return func(o *options) {
+		o.status[level.String()] = code
+	}
+}
+
+// defaultStatus returns the severity to span status mapping. Tiny errors
+// are expected by the application and leave the span status unset.
+func defaultStatus() map[string]codes.Code {
+	return map[string]codes.Code{
+		errors.Tiny.String(): codes.Unset,
+	}
+}
+
+// RecordError records err onto the span found in ctx.
+// It does nothing if err is nil or the span is not recording.
+func RecordError(ctx context.Context, err error, opts ...Option) {
+	RecordErrorOnSpan(trace.SpanFromContext(ctx), err, opts...)
+}
+
+// RecordErrorOnSpan records err onto the span.
+// It does nothing if err is nil or the span is not recording.
+func RecordErrorOnSpan(span trace.Span, err error, opts ...Option) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	o := options{status: defaultStatus()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	se := errors.Serialize(err, errors.WithAttributes(errors.AddStack|errors.AddFields))
+
+	span.RecordError(err, trace.WithAttributes(attributes(se)...))
+
+	code, ok := o.status[se.Severity]
+	if !ok {
+		code = codes.Error
+	}
+	if code != codes.Unset {
+		span.SetStatus(code, err.Error())
+	}
+
+	var e *errors.Error
+	if errors.As(err, &e) {
+		copySpanContext(e, span.SpanContext())
+	}
+}
+
+func attributes(se *errors.SerializedError) []attribute.KeyValue {
+	res := make([]attribute.KeyValue, 0, 4+len(se.Fields))
+
+	if se.Code != "" {
+		res = append(res, CodeKey.String(se.Code))
+	}
+	if se.Severity != "" {
+		res = append(res, SeverityKey.String(se.Severity))
+	}
+	if se.StatusCode != 0 {
+		res = append(res, StatusCodeKey.Int(se.StatusCode))
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(se.Fields)) {
+		res = append(res, fieldAttribute(FieldKeyPrefix+k, se.Fields[k]))
+	}
+
+	if len(se.Stack) > 0 {
+		res = append(res, StacktraceKey.String(stacktrace(se.Stack)))
+	}
+	return res
+}
+
+func fieldAttribute(key string, value any) attribute.KeyValue {
+	k := attribute.Key(key)
+	switch v := value.(type) {
+	case string:
+		return k.String(v)
+	case bool:
+		return k.Bool(v)
+	case int:
+		return k.Int(v)
+	case int64:
+		return k.Int64(v)
+	case float64:
+		return k.Float64(v)
+	case []string:
+		return k.StringSlice(v)
+	case fmt.Stringer:
+		return k.String(v.String())
+	}
+	return k.String(fmt.Sprint(value))
+}
+
+// stacktrace formats frames the way runtime/debug.Stack does, which is
+// what OpenTelemetry backends expect in exception.stacktrace.
+func stacktrace(frames []errors.StackFrame) string {
+	var sb strings.Builder
+	for _, f := range frames {
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func copySpanContext(e *errors.Error, sc trace.SpanContext) {
+	if sc.HasTraceID() {
+		if _, ok := errors.Field(e, TraceIDField); !ok {
+			e.Set(TraceIDField, sc.TraceID().String())
+		}
+	}
+	if sc.HasSpanID() {
+		if _, ok := errors.Field(e, SpanIDField); !ok {
+			e.Set(SpanIDField, sc.SpanID().String())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/axkit/errors"
+)
+
+func newTracer(t *testing.T) (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return exporter, tp
+}
+
+func eventAttributes(t *testing.T, exporter *tracetest.InMemoryExporter) (tracetest.SpanStub, map[attribute.Key]attribute.Value) {
+	t.Helper()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if len(spans[0].Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(spans[0].Events))
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Events[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return spans[0], attrs
+}
+
+func TestRecordError(t *testing.T) {
+	exporter, tp := newTracer(t)
+
+	ErrCustomerNotFound := errors.Template("customer not found").
+		Code("CRM-0404").
+		StatusCode(404).
+		Severity(errors.Medium)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	err := ErrCustomerNotFound.Wrap(io.EOF).Set("customerId", 42).Set("retry", true)
+	RecordError(ctx, err)
+	span.End()
+
+	stub, attrs := eventAttributes(t, exporter)
+
+	expected := map[attribute.Key]attribute.Value{
+		CodeKey:                       attribute.StringValue("CRM-0404"),
+		SeverityKey:                   attribute.StringValue("medium"),
+		StatusCodeKey:                 attribute.IntValue(404),
+		FieldKeyPrefix + "customerId": attribute.IntValue(42),
+		FieldKeyPrefix + "retry":      attribute.BoolValue(true),
+		"exception.message":           attribute.StringValue("customer not found: EOF"),
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Errorf("attribute %s: expected %v, got %v", k, v.Emit(), attrs[k].Emit())
+		}
+	}
+
+	if st := attrs[StacktraceKey].AsString(); !strings.Contains(st, "TestRecordError") {
+		t.Errorf("expected stacktrace to contain test function, got %q", st)
+	}
+
+	if stub.Status.Code != codes.Error || stub.Status.Description != "customer not found: EOF" {
+		t.Errorf("expected error status, got %v", stub.Status)
+	}
+
+	if v, _ := errors.Field(err, TraceIDField); v != stub.SpanContext.TraceID().String() {
+		t.Errorf("expected trace id %s, got %v", stub.SpanContext.TraceID(), v)
+	}
+	if v, _ := errors.Field(err, SpanIDField); v != stub.SpanContext.SpanID().String() {
+		t.Errorf("expected span id %s, got %v", stub.SpanContext.SpanID(), v)
+	}
+}
+
+func TestRecordError_SeverityStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity errors.SeverityLevel
+		opts     []Option
+		expected codes.Code
+	}{
+		{"tiny", errors.Tiny, nil, codes.Unset},
+		{"critical", errors.Critical, nil, codes.Error},
+		{"unknown", errors.Unknown, nil, codes.Error},
+		{"overridden", errors.Tiny, []Option{WithSeverityStatus(errors.Tiny, codes.Error)}, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, tp := newTracer(t)
+
+			ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+			RecordError(ctx, errors.Template("failure").Severity(tt.severity).New(), tt.opts...)
+			span.End()
+
+			stub, _ := eventAttributes(t, exporter)
+			if stub.Status.Code != tt.expected {
+				t.Errorf("expected status %v, got %v", tt.expected, stub.Status.Code)
+			}
+		})
+	}
+}
+
+func TestRecordError_KeepsExistingTraceID(t *testing.T) {
+	_, tp := newTracer(t)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	defer span.End()
+
+	err := errors.Template("failure").New().Set(TraceIDField, "origin")
+	RecordError(ctx, err)
+
+	if v, _ := errors.Field(err, TraceIDField); v != "origin" {
+		t.Errorf("expected trace id to be kept, got %v", v)
+	}
+}
+
+func TestRecordError_WrappedByStandardError(t *testing.T) {
+	_, tp := newTracer(t)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	defer span.End()
+
+	err := errors.Template("failure").New()
+	RecordError(ctx, fmt.Errorf("handler: %w", err))
+
+	if v, _ := errors.Field(err, TraceIDField); v != span.SpanContext().TraceID().String() {
+		t.Errorf("expected trace id %s, got %v", span.SpanContext().TraceID(), v)
+	}
+}
+
+func TestRecordError_NoOp(t *testing.T) {
+	exporter, tp := newTracer(t)
+
+	RecordError(context.Background(), errors.Template("failure").New())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	RecordError(ctx, nil)
+	span.End()
+
+	if spans := exporter.GetSpans(); len(spans) != 1 || len(spans[0].Events) != 0 {
+		t.Errorf("expected no events, got %v", spans)
+	}
+}
+
+func TestRecordError_StandardError(t *testing.T) {
+	exporter, tp := newTracer(t)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	RecordError(ctx, io.EOF)
+	span.End()
+
+	stub, attrs := eventAttributes(t, exporter)
+	if attrs["exception.message"].AsString() != "EOF" {
+		t.Errorf("expected exception message EOF, got %v", attrs["exception.message"].Emit())
+	}
+	if stub.Status.Code != codes.Error {
+		t.Errorf("expected error status, got %v", stub.Status.Code)
+	}
+}
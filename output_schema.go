@@ -0,0 +1,156 @@
+package errors
+
+import "strings"
+
+// OutputSchema sets the JSON keys of the serialized error members.
+// An empty key stands for the default one, like "msg" for Message,
+// and "-" omits the member.
+//
+// Keys are written as is, so a dotted key like "error.code" is a single
+// member of the object; Elasticsearch and OpenTelemetry collectors expand
+// dotted keys themselves.
+type OutputSchema struct {
+	Message    string
+	Severity   string
+	Code       string
+	StatusCode string
+	Fields     string
+	Wrapped    string
+	Stack      string
+
+	// FlattenFields writes the fields as members of the error object,
+	// keyed by FieldPrefix followed by the field name, instead of
+	// the Fields object. Fields whose keys would collide with other
+	// members stay in the Fields object.
+	FlattenFields bool
+	FieldPrefix   string
+
+	// StackText writes the stack as a single string formatted like
+	// a Go panic trace instead of an array of frames.
+	StackText bool
+}
+
+var (
+	// ECSOutputSchema follows the Elastic Common Schema: the fields are
+	// flattened into the root and the stack is written as text.
+	ECSOutputSchema = OutputSchema{
+		Message:       "message",
+		Severity:      "log.level",
+		Code:          "error.code",
+		StatusCode:    "http.response.status_code",
+		Wrapped:       "error.wrapped",
+		Stack:         "error.stack_trace",
+		FlattenFields: true,
+		StackText:     true,
+	}
+
+	// OTelOutputSchema follows the OpenTelemetry semantic conventions for
+	// exceptions and log records: the fields are flattened into the root
+	// as attributes and the stack is written as text.
+	OTelOutputSchema = OutputSchema{
+		Message:       "exception.message",
+		Severity:      "severity_text",
+		Code:          "error.type",
+		StatusCode:    "http.response.status_code",
+		Wrapped:       "exception.wrapped",
+		Stack:         "exception.stacktrace",
+		FlattenFields: true,
+		StackText:     true,
+	}
+)
+
+// defaultOutputSchema holds the keys of SerializedError's JSON tags.
+var defaultOutputSchema = OutputSchema{}.resolve()
+
+// WithOutputSchema sets the JSON keys and layout used by ToJSON, AppendJSON,
+// WriteJSON and the JSON formatters. Serialize records the resolved schema
+// in SerializedError.Schema for custom serializers.
+func WithOutputSchema(s OutputSchema) Option {
+	s = s.resolve()
+	return func(e *ErrorFormattingOptions) {
+		e.schema = &s
+	}
+}
+
+// resolve returns a copy of the schema with the defaults filled in
+// and omitted members' keys set to empty strings.
+func (s OutputSchema) resolve() OutputSchema {
+	keys := [...]struct {
+		key *string
+		def string
+	}{
+		{&s.Message, "msg"},
+		{&s.Severity, "severity"},
+		{&s.Code, "code"},
+		{&s.StatusCode, "statusCode"},
+		{&s.Fields, "fields"},
+		{&s.Wrapped, "wrapped"},
+		{&s.Stack, "stack"},
+	}
+	for _, k := range keys {
+		switch *k.key {
+		case "":
+			*k.key = k.def
+		case "-":
+			*k.key = ""
+		}
+	}
+	return s
+}
+
+// isMember reports whether the key belongs to a member of the error object.
+func (s *OutputSchema) isMember(key string) bool {
+	if key == "" {
+		return false
+	}
+	switch key {
+	case s.Message, s.Severity, s.Code, s.StatusCode:
+		return true
+	}
+	return s.isNonScalar(key)
+}
+
+// isNonScalar reports whether the key belongs to the fields,
+// wrapped errors or stack member.
+func (s *OutputSchema) isNonScalar(key string) bool {
+	if key == "" {
+		return false
+	}
+	return key == s.Fields || key == s.Wrapped || key == s.Stack
+}
+
+// FlattenedFields returns the fields the schema writes as members of
+// the error object, keyed by FieldPrefix followed by the field name.
+// Fields colliding with other members, and root-level fields, are left
+// out. It returns nil if the schema doesn't flatten fields.
+func (serr *SerializedError) FlattenedFields() map[string]any {
+	if serr.Schema == nil || !serr.Schema.FlattenFields {
+		return nil
+	}
+
+	var res map[string]any
+	for k, v := range serr.Fields {
+		if isPromoted(serr.root, k) || inFieldsObject(k, serr.root, serr.Schema) {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]any, len(serr.Fields))
+		}
+		res[serr.Schema.FieldPrefix+k] = v
+	}
+	return res
+}
+
+// stackText formats the stack as a Go panic trace.
+func stackText(stack []StackFrame) string {
+	var sb strings.Builder
+	for i, f := range stack {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+	}
+	return sb.String()
+}
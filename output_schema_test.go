@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOutputSchema_resolve(t *testing.T) {
+	got := OutputSchema{Message: "message", Stack: "-"}.resolve()
+	expected := OutputSchema{
+		Message:    "message",
+		Severity:   "severity",
+		Code:       "code",
+		StatusCode: "statusCode",
+		Fields:     "fields",
+		Wrapped:    "wrapped",
+	}
+	if got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestAppendSerializedError_OutputSchema(t *testing.T) {
+	serr := SerializedError{
+		Message:    "customer not found",
+		Severity:   "tiny",
+		Code:       "CRM-0404",
+		StatusCode: 404,
+		Fields:     map[string]any{"customerId": 42, "message": "shadowed"},
+		Wrapped:    []SerializedError{{Message: "customer not found", Code: "CRM-0404"}, {Message: "EOF"}},
+		Stack: []StackFrame{
+			{Function: "main.handler", File: "/app/handler.go:42", Line: 42},
+			{Function: "main.main", File: "/app/main.go:10", Line: 10},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		schema    OutputSchema
+		rootLevel []string
+		expected  string
+	}{
+		{
+			name:   "ecs",
+			schema: ECSOutputSchema,
+			expected: `{"message":"customer not found","log.level":"tiny","error.code":"CRM-0404","http.response.status_code":404,` +
+				`"customerId":42,"fields":{"message":"shadowed"},` +
+				`"error.wrapped":[{"message":"customer not found","error.code":"CRM-0404"},{"message":"EOF"}],` +
+				`"error.stack_trace":"main.handler\n\t/app/handler.go:42\nmain.main\n\t/app/main.go:10"}`,
+		},
+		{
+			name:   "otel",
+			schema: OTelOutputSchema,
+			expected: `{"exception.message":"customer not found","severity_text":"tiny","error.type":"CRM-0404","http.response.status_code":404,` +
+				`"customerId":42,"message":"shadowed",` +
+				`"exception.wrapped":[{"exception.message":"customer not found","error.type":"CRM-0404"},{"exception.message":"EOF"}],` +
+				`"exception.stacktrace":"main.handler\n\t/app/handler.go:42\nmain.main\n\t/app/main.go:10"}`,
+		},
+		{
+			name:   "renamed and omitted members",
+			schema: OutputSchema{Message: "error", Severity: "-", StatusCode: "-", Wrapped: "-", Stack: "trace"},
+			expected: `{"error":"customer not found","code":"CRM-0404","fields":{"customerId":42,"message":"shadowed"},` +
+				`"trace":[{"func":"main.handler","file":"/app/handler.go:42","line":42},{"func":"main.main","file":"/app/main.go:10","line":10}]}`,
+		},
+		{
+			name:   "prefixed fields",
+			schema: OutputSchema{FlattenFields: true, FieldPrefix: "labels.", Wrapped: "-", Stack: "-"},
+			expected: `{"msg":"customer not found","severity":"tiny","code":"CRM-0404","statusCode":404,` +
+				`"labels.customerId":42,"labels.message":"shadowed"}`,
+		},
+		{
+			name:      "root level fields",
+			schema:    OutputSchema{Code: "error.code", Wrapped: "-", Stack: "-"},
+			rootLevel: []string{"customerId", "message"},
+			expected: `{"msg":"customer not found","severity":"tiny","error.code":"CRM-0404","statusCode":404,` +
+				`"customerId":42,"message":"shadowed"}`,
+		},
+		{
+			name:      "root level fields colliding with members",
+			schema:    ECSOutputSchema,
+			rootLevel: []string{"message"},
+			expected: `{"message":"customer not found","log.level":"tiny","error.code":"CRM-0404","http.response.status_code":404,` +
+				`"customerId":42,` +
+				`"error.wrapped":[{"message":"customer not found","error.code":"CRM-0404"},{"message":"EOF"}],` +
+				`"error.stack_trace":"main.handler\n\t/app/handler.go:42\nmain.main\n\t/app/main.go:10",` +
+				`"fields.message":"shadowed"}`,
+		},
+		{
+			name:     "everything omitted",
+			schema:   OutputSchema{Message: "-", Severity: "-", Code: "-", StatusCode: "-", Fields: "-", Wrapped: "-", Stack: "-"},
+			expected: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := tt.schema.resolve()
+			serr := serr
+			serr.root = testRootFields(&serr, tt.rootLevel)
+
+			got := appendRootError(nil, &serr, &ErrorFormattingOptions{schema: &schema})
+			if string(got) != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+			if !json.Valid(got) {
+				t.Errorf("invalid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func TestToJSON_WithOutputSchema(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").Severity(Tiny).New().
+		Set("customerId", 42)
+
+	expected := `{"message":"customer not found","log.level":"tiny","error.code":"CRM-0404","customerId":42}`
+	if got := string(ToJSON(err, WithOutputSchema(ECSOutputSchema))); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	expected = "{\n  \"message\": \"customer not found\",\n  \"log.level\": \"tiny\",\n  \"error.code\": \"CRM-0404\",\n  \"customerId\": 42\n}"
+	f, _ := LookupFormatter(FormatIndentJSON)
+	if got := string(f.Format(err, WithOutputSchema(ECSOutputSchema))); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	// The default keys are used without the option.
+	expected = `{"msg":"customer not found","severity":"tiny","code":"CRM-0404","fields":{"customerId":42}}`
+	if got := string(ToJSON(err)); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestSerialize_WithOutputSchema(t *testing.T) {
+	err := Template("customer not found").Code("CRM-0404").New().
+		Set("customerId", 42).
+		Set("message", "kept").
+		Set("requestId", "req-1")
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		key       string
+		flattened map[string]any
+	}{
+		{
+			name: "default",
+			key:  "code",
+		},
+		{
+			name:      "ECS",
+			opts:      []Option{WithOutputSchema(ECSOutputSchema)},
+			key:       "error.code",
+			flattened: map[string]any{"customerId": 42, "requestId": "req-1"},
+		},
+		{
+			name:      "prefix and root-level field",
+			opts:      []Option{WithOutputSchema(OutputSchema{FlattenFields: true, FieldPrefix: "labels."}), WithRootLevelFields([]string{"requestId"})},
+			key:       "code",
+			flattened: map[string]any{"labels.customerId": 42, "labels.message": "kept"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			se := Serialize(err, tt.opts...)
+			if se.Schema == nil || se.Schema.Code != tt.key {
+				t.Fatalf("expected code key %q, got %+v", tt.key, se.Schema)
+			}
+			if got := se.FlattenedFields(); !reflect.DeepEqual(got, tt.flattened) {
+				t.Errorf("expected %v, got %v", tt.flattened, got)
+			}
+		})
+	}
+}
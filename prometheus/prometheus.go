@@ -0,0 +1,75 @@
+// Package prometheus exposes error counters collected by
+// github.com/axkit/errors as Prometheus metrics.
+//
+//	c := prometheus.NewCollector("myapp")
+//	registry.MustRegister(c)
+//	errors.SetMetricsRecorder(c)
+//
+// It produces two counters labelled by error code, severity and status code:
+// <namespace>_errors_created_total and <namespace>_errors_serialized_total.
+package prometheus
+
+import (
+	"strconv"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/axkit/errors"
+)
+
+var labelNames = []string{"code", "severity", "statusCode"}
+
+// Collector implements prometheus.Collector and errors.MetricsRecorder.
+type Collector struct {
+	created    *prom.CounterVec
+	serialized *prom.CounterVec
+}
+
+var (
+	_ prom.Collector         = (*Collector)(nil)
+	_ errors.MetricsRecorder = (*Collector)(nil)
+)
+
+// NewCollector returns a new Collector. Metric names are prefixed with
+// the namespace if it's not empty.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		created: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_created_total",
+			Help:      "Number of errors created.",
+		}, labelNames),
+		serialized: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_serialized_total",
+			Help:      "Number of errors serialized.",
+		}, labelNames),
+	}
+}
+
+// RecordError implements errors.MetricsRecorder interface.
+func (c *Collector) RecordError(event errors.MetricEvent, labels errors.MetricLabels) {
+	var vec *prom.CounterVec
+	switch event {
+	case errors.MetricErrorCreated:
+		vec = c.created
+	case errors.MetricErrorSerialized:
+		vec = c.serialized
+	default:
+		return
+	}
+
+	vec.WithLabelValues(labels.Code, labels.Severity.String(), strconv.Itoa(labels.StatusCode)).Inc()
+}
+
+// Describe implements prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	c.created.Describe(ch)
+	c.serialized.Describe(ch)
+}
+
+// Collect implements prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.created.Collect(ch)
+	c.serialized.Collect(ch)
+}
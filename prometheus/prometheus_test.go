@@ -0,0 +1,49 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/axkit/errors"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector("app")
+	errors.SetMetricsRecorder(c)
+	t.Cleanup(func() { errors.SetMetricsRecorder(nil) })
+
+	reg := prom.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ErrInvalidInput := errors.Template("invalid input").Code("CRM-0901").StatusCode(400).Severity(errors.Tiny)
+
+	err := ErrInvalidInput.New()
+	_ = errors.Template("handler failed").Wrap(ErrInvalidInput.New())
+	_ = errors.ToJSON(err)
+
+	expected := `
+# HELP app_errors_created_total Number of errors created.
+# TYPE app_errors_created_total counter
+app_errors_created_total{code="CRM-0901",severity="tiny",statusCode="400"} 2
+# HELP app_errors_serialized_total Number of errors serialized.
+# TYPE app_errors_serialized_total counter
+app_errors_serialized_total{code="CRM-0901",severity="tiny",statusCode="400"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollector_UnknownEvent(t *testing.T) {
+	c := NewCollector("")
+	c.RecordError(errors.MetricEvent(0), errors.MetricLabels{Code: "X"})
+
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("expected no metrics, got %d", n)
+	}
+}
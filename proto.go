@@ -0,0 +1,384 @@
+package errors
+
+import (
+	"encoding/json"
+	se "errors"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrUnmarshalError is returned when an error can't be decoded.
+var ErrUnmarshalError = Template("error unmarshaling failed").Severity(Tiny)
+
+// Field numbers of the Error message in proto/errors.proto.
+const (
+	protoMessage      protowire.Number = 1
+	protoCode         protowire.Number = 2
+	protoSeverity     protowire.Number = 3
+	protoSeverityName protowire.Number = 4
+	protoStatusCode   protowire.Number = 5
+	protoProtected    protowire.Number = 6
+	protoFields       protowire.Number = 7
+	protoStack        protowire.Number = 8
+	protoWrapped      protowire.Number = 9
+	protoPureWrapper  protowire.Number = 10
+	protoFormatted    protowire.Number = 11
+	protoOpaque       protowire.Number = 12
+	protoSharedStack  protowire.Number = 13
+	protoTemplate     protowire.Number = 14
+)
+
+// Field numbers of the StackFrame message in proto/errors.proto.
+const (
+	protoFrameFunction protowire.Number = 1
+	protoFrameFile     protowire.Number = 2
+	protoFrameLine     protowire.Number = 3
+)
+
+// MarshalProto encodes the error and its wrapped chain in the binary format
+// described by proto/errors.proto.
+//
+// Fields are encoded as google.protobuf.Struct, so values are limited to
+// what JSON can represent: numbers are decoded as float64, and values of
+// other types are converted through their JSON encoding. Sensitive fields are
+// redacted unless WithAttributes(AddSensitive) is given. Errors that don't
+// belong to this package keep only their messages, along with the errors
+// of this package they wrap.
+func (e *Error) MarshalProto(opts ...Option) ([]byte, error) {
+	var option ErrorFormattingOptions
+	for _, opt := range opts {
+		opt(&option)
+	}
+
+	notify(EventSerialize, e)
+	return appendProtoError(nil, e, e, &option)
+}
+
+func appendProtoError(b []byte, top error, e *Error, option *ErrorFormattingOptions) ([]byte, error) {
+	msg, template := protoMessageText(e.message)
+	b = appendProtoString(b, protoMessage, msg)
+	b = appendProtoBool(b, protoTemplate, template)
+	b = appendProtoString(b, protoCode, e.code)
+	if e.severity != 0 {
+		if int(int32(e.severity)) != int(e.severity) {
+			return nil, ErrMarshalError.Wrap(New("severity level out of range")).Set("severity", int(e.severity))
+		}
+		b = protowire.AppendTag(b, protoSeverity, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(int64(e.severity)))
+		b = appendProtoString(b, protoSeverityName, e.severity.String())
+	}
+	if e.statusCode != 0 {
+		b = protowire.AppendTag(b, protoStatusCode, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(int32(e.statusCode))))
+	}
+	b = appendProtoBool(b, protoProtected, e.protected)
+
+	if fields := redactFields(top, e.fields, option); len(fields) > 0 {
+		s, err := protoStruct(fields)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := proto.Marshal(s)
+		if err != nil {
+			return nil, ErrMarshalError.Wrap(err)
+		}
+		b = protowire.AppendTag(b, protoFields, protowire.BytesType)
+		b = protowire.AppendBytes(b, buf)
+	}
+
+	var inner *Error
+	if x, ok := e.err.(*Error); ok {
+		inner = x
+	}
+
+	if inner != nil && len(e.stack) > 0 && sameStack(e.stack, inner.stack) {
+		b = appendProtoBool(b, protoSharedStack, true)
+	} else {
+		for _, f := range e.stack {
+			b = protowire.AppendTag(b, protoStack, protowire.BytesType)
+			b = protowire.AppendBytes(b, appendProtoFrame(nil, f))
+		}
+	}
+
+	b = appendProtoBool(b, protoPureWrapper, e.pureWrapper)
+	b = appendProtoBool(b, protoFormatted, e.formatted)
+
+	return appendProtoWrapped(b, top, e.err, option)
+}
+
+// appendProtoWrapped appends the wrapped error. An error that doesn't belong
+// to this package is encoded as opaque, keeping its message; the errors
+// of this package it wraps, like fmt.Errorf with %w does, are encoded
+// as its wrapped chain.
+func appendProtoWrapped(b []byte, top error, err error, option *ErrorFormattingOptions) ([]byte, error) {
+	var (
+		buf  []byte
+		ferr error
+	)
+
+	switch x := err.(type) {
+	case nil:
+		return b, nil
+	case *Error:
+		buf, ferr = appendProtoError(nil, top, x, option)
+	case *ErrorTemplate:
+		buf, ferr = appendProtoError(nil, top, x.toError(), option)
+	default:
+		buf = appendProtoString(nil, protoMessage, err.Error())
+		buf = appendProtoBool(buf, protoOpaque, true)
+		if next := se.Unwrap(err); hasChainLevels(next) {
+			buf, ferr = appendProtoWrapped(buf, top, next, option)
+		}
+	}
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	b = protowire.AppendTag(b, protoWrapped, protowire.BytesType)
+	return protowire.AppendBytes(b, buf), nil
+}
+
+// hasChainLevels reports whether the chain of err holds errors
+// or templates of this package.
+func hasChainLevels(err error) bool {
+	for range chainLevels(err) {
+		return true
+	}
+	return false
+}
+
+// opaqueError is a decoded error that doesn't belong to this package,
+// wrapping decoded errors of this package.
+type opaqueError struct {
+	msg string
+	err error
+}
+
+func (e *opaqueError) Error() string { return e.msg }
+
+func (e *opaqueError) Unwrap() error { return e.err }
+
+func appendProtoFrame(b []byte, f StackFrame) []byte {
+	b = appendProtoString(b, protoFrameFunction, f.Function)
+	b = appendProtoString(b, protoFrameFile, f.File)
+	if f.Line != 0 {
+		b = protowire.AppendTag(b, protoFrameLine, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(int32(f.Line))))
+	}
+	return b
+}
+
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendProtoBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+// sameStack reports whether both slices refer to the same stack trace.
+func sameStack(a, b []StackFrame) bool {
+	return len(a) == len(b) && len(a) > 0 && &a[0] == &b[0]
+}
+
+// protoStruct converts fields to google.protobuf.Struct. Values that
+// structpb doesn't support are converted through their JSON encoding,
+// with values that can't be encoded replaced as in ToJSON.
+func protoStruct(fields map[string]any) (*structpb.Struct, error) {
+	res := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(fields))}
+	for k, v := range fields {
+		pv, err := structpb.NewValue(v)
+		if err != nil {
+			buf, ferr := appendFieldValue(nil, v)
+			if ferr != nil && alarmer != nil {
+				alarmer.Alarm(ErrMarshalError.Wrap(ferr).Set("field", k))
+			}
+
+			var jv any
+			if err := json.Unmarshal(buf, &jv); err != nil {
+				return nil, ErrMarshalError.Wrap(err).Set("field", k)
+			}
+			if pv, err = structpb.NewValue(jv); err != nil {
+				return nil, ErrMarshalError.Wrap(err).Set("field", k)
+			}
+		}
+		res.Fields[k] = pv
+	}
+	return res, nil
+}
+
+// protoMessageText returns the message as it's encoded. A message without
+// placeholders is encoded as the text it renders to; otherwise it's kept
+// in the template syntax, which is reported by the second result.
+func protoMessageText(msg string) (string, bool) {
+	if len(placeholders(msg)) > 0 {
+		return msg, true
+	}
+	return rawMessage(msg), false
+}
+
+// UnmarshalProto decodes the error and its wrapped chain encoded by
+// MarshalProto, replacing the content of e. Decoded errors match their
+// templates with Is.
+func (e *Error) UnmarshalProto(b []byte) error {
+	res, _, err := consumeProtoError(b)
+	if err != nil {
+		return err
+	}
+	*e = *res
+	return nil
+}
+
+// consumeProtoError decodes a single level of the chain. It reports whether
+// the level stands for an error that doesn't belong to this package.
+func consumeProtoError(b []byte) (*Error, bool, error) {
+	var (
+		res         Error
+		opaque      bool
+		sharedStack bool
+		template    bool
+	)
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, false, protoError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, false, protoError(n)
+			}
+			b = b[n:]
+
+			switch num {
+			case protoMessage:
+				res.message = string(v)
+			case protoCode:
+				res.code = string(v)
+			case protoFields:
+				var s structpb.Struct
+				if err := proto.Unmarshal(v, &s); err != nil {
+					return nil, false, ErrUnmarshalError.Wrap(err)
+				}
+				res.fields = s.AsMap()
+			case protoStack:
+				f, err := consumeProtoFrame(v)
+				if err != nil {
+					return nil, false, err
+				}
+				res.stack = append(res.stack, f)
+			case protoWrapped:
+				w, opaque, err := consumeProtoError(v)
+				if err != nil {
+					return nil, false, err
+				}
+				switch {
+				case opaque && w.err != nil:
+					res.err = &opaqueError{msg: w.message, err: w.err}
+				case opaque:
+					res.err = se.New(w.message)
+				default:
+					res.err = w
+				}
+			}
+		case typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, false, protoError(n)
+			}
+			b = b[n:]
+
+			switch num {
+			case protoSeverity:
+				res.severity = SeverityLevel(int32(protowire.DecodeZigZag(v)))
+			case protoStatusCode:
+				res.statusCode = int(int32(v))
+			case protoProtected:
+				res.protected = v != 0
+			case protoPureWrapper:
+				res.pureWrapper = v != 0
+			case protoFormatted:
+				res.formatted = v != 0
+			case protoOpaque:
+				opaque = v != 0
+			case protoSharedStack:
+				sharedStack = v != 0
+			case protoTemplate:
+				template = v != 0
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, false, protoError(n)
+			}
+			b = b[n:]
+		}
+	}
+
+	if w, ok := res.err.(*Error); ok && sharedStack {
+		res.stack = w.stack
+	}
+	if !template && !opaque {
+		// The text is rendered verbatim, as messages given to Wrap are.
+		res.message = braceEscaper.Replace(res.message)
+	}
+	return &res, opaque, nil
+}
+
+func consumeProtoFrame(b []byte) (StackFrame, error) {
+	var res StackFrame
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return res, protoError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case typ == protowire.BytesType && (num == protoFrameFunction || num == protoFrameFile):
+			v, n := protowire.ConsumeString(b)
+			if n < 0 {
+				return res, protoError(n)
+			}
+			b = b[n:]
+			if num == protoFrameFunction {
+				res.Function = v
+			} else {
+				res.File = v
+			}
+		case typ == protowire.VarintType && num == protoFrameLine:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return res, protoError(n)
+			}
+			b = b[n:]
+			res.Line = int(int32(v))
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return res, protoError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return res, nil
+}
+
+func protoError(n int) error {
+	return ErrUnmarshalError.Wrap(protowire.ParseError(n))
+}
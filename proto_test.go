@@ -0,0 +1,399 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestError_MarshalProto(t *testing.T) {
+	errCustomerNotFound := Template("customer {customerId} not found").
+		Code("CRM-0404").
+		Severity(Tiny).
+		StatusCode(404)
+	errLookupFailed := Template("lookup failed").Code("CRM-0500").Protected(true)
+
+	type point struct {
+		X int `json:"x"`
+	}
+
+	inner := errCustomerNotFound.Wrap(io.EOF).
+		Set("customerId", 42).
+		Set("point", point{X: 1}).
+		Set("tags", []string{"a", "b"})
+	outer := errLookupFailed.Wrap(inner).Set("requestId", "req-1")
+
+	buf, err := outer.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got Error
+	if err := got.UnmarshalProto(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Error() != outer.Error() {
+		t.Errorf("expected message %q, got %q", outer.Error(), got.Error())
+	}
+
+	if !got.metadata.equal(outer.metadata) {
+		t.Errorf("expected metadata %+v, got %+v", outer.metadata, got.metadata)
+	}
+
+	if !Is(&got, errLookupFailed) || !Is(&got, errCustomerNotFound) {
+		t.Errorf("expected decoded error to match its templates")
+	}
+
+	expectedFields := map[string]any{
+		"customerId": float64(42),
+		"point":      map[string]any{"x": float64(1)},
+		"tags":       []any{"a", "b"},
+		"requestId":  "req-1",
+	}
+	if fields := Fields(&got); !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected fields %v, got %v", expectedFields, fields)
+	}
+
+	if !reflect.DeepEqual(got.stack, outer.stack) {
+		t.Errorf("expected stack %v, got %v", outer.stack, got.stack)
+	}
+
+	w, ok := got.err.(*Error)
+	if !ok {
+		t.Fatalf("expected wrapped *Error, got %T", got.err)
+	}
+	if !sameStack(got.stack, w.stack) {
+		t.Errorf("expected the stack to be shared with the wrapped error")
+	}
+	if w.err == nil || w.err.Error() != io.EOF.Error() {
+		t.Errorf("expected opaque wrapped error %q, got %v", io.EOF.Error(), w.err)
+	}
+}
+
+func TestError_MarshalProtoStandardWrapper(t *testing.T) {
+	errCustomerNotFound := Template("customer not found").Code("CRM-0404")
+	errLookupFailed := Template("lookup failed").Code("CRM-0500")
+
+	inner := errCustomerNotFound.Wrap(io.EOF).Set("customerId", 42)
+	outer := errLookupFailed.Wrap(fmt.Errorf("loading: %w", inner))
+
+	buf, err := outer.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got Error
+	if err := got.UnmarshalProto(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Error() != outer.Error() {
+		t.Errorf("expected message %q, got %q", outer.Error(), got.Error())
+	}
+	if !Is(&got, errCustomerNotFound) {
+		t.Errorf("expected the error wrapped by the standard error to be kept")
+	}
+	if v, _ := Field(&got, "customerId"); v != float64(42) {
+		t.Errorf("expected field of the inner error, got %v", v)
+	}
+
+	w, ok := got.err.(*opaqueError)
+	if !ok || w.Error() != "loading: customer not found: EOF" {
+		t.Fatalf("expected opaque wrapper, got %T %v", got.err, got.err)
+	}
+	if x, ok := w.err.(*Error); !ok || x.err == nil || x.err.Error() != "EOF" {
+		t.Errorf("expected inner error wrapping EOF, got %v", w.err)
+	}
+}
+
+func TestError_MarshalProtoBraces(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *Error
+		message  string
+		template bool
+		expected string
+	}{
+		{
+			name:     "verbatim",
+			err:      Wrap(io.EOF, "bad {json}"),
+			message:  "bad {json}",
+			expected: "bad {json}: EOF",
+		},
+		{
+			name:     "formatted",
+			err:      Errorf("bad {%s}: %w", "json", io.EOF),
+			message:  "bad {json}: EOF",
+			expected: "bad {json}: EOF",
+		},
+		{
+			name:     "escaped braces in a template",
+			err:      Template("bad {{json}}").New(),
+			message:  "bad {json}",
+			expected: "bad {json}",
+		},
+		{
+			name:     "template",
+			err:      Template("customer {customerId} not found in {{db}}").New().Set("customerId", 42),
+			message:  "customer {customerId} not found in {{db}}",
+			template: true,
+			expected: "customer 42 not found in {db}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := tt.err.MarshalProto()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			message, template := protoMessageText(tt.err.message)
+			if message != tt.message || template != tt.template {
+				t.Errorf("expected message %q, template %v, got %q, %v", tt.message, tt.template, message, template)
+			}
+
+			var got Error
+			if err := got.UnmarshalProto(buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Error() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got.Error())
+			}
+			if got.message != tt.err.message {
+				t.Errorf("expected internal message %q, got %q", tt.err.message, got.message)
+			}
+		})
+	}
+}
+
+func TestError_MarshalProtoSeverity(t *testing.T) {
+	tests := []struct {
+		name  string
+		level SeverityLevel
+	}{
+		{"registered", Critical},
+		{"negative", SeverityLevel(-5)},
+		{"large", SeverityLevel(1 << 20)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := Template("failure").Severity(tt.level).New().MarshalProto()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got Error
+			if err := got.UnmarshalProto(buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.severity != tt.level {
+				t.Errorf("expected severity %d, got %d", tt.level, got.severity)
+			}
+		})
+	}
+
+	if _, err := Template("failure").Severity(SeverityLevel(1 << 40)).New().MarshalProto(); !Is(err, ErrMarshalError) {
+		t.Errorf("expected ErrMarshalError for out of range severity, got %v", err)
+	}
+}
+
+func TestError_MarshalProtoSensitive(t *testing.T) {
+	err := Template("login failed").Sensitive("password", RedactDrop).New().
+		Set("password", "secret").
+		Set("user", "john")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected map[string]any
+	}{
+		{
+			name:     "redacted",
+			expected: map[string]any{"user": "john"},
+		},
+		{
+			name:     "with sensitive",
+			opts:     []Option{WithAttributes(AddSensitive)},
+			expected: map[string]any{"user": "john", "password": "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, merr := err.MarshalProto(tt.opts...)
+			if merr != nil {
+				t.Fatalf("unexpected error: %v", merr)
+			}
+
+			var got Error
+			if uerr := got.UnmarshalProto(buf); uerr != nil {
+				t.Fatalf("unexpected error: %v", uerr)
+			}
+			if !reflect.DeepEqual(got.fields, tt.expected) {
+				t.Errorf("expected fields %v, got %v", tt.expected, got.fields)
+			}
+		})
+	}
+}
+
+func TestError_MarshalProtoUnsupported(t *testing.T) {
+	err := Template("failure").New().
+		Set("ch", make(chan int)).
+		Set("n", 1)
+
+	buf, merr := err.MarshalProto()
+	if merr != nil {
+		t.Fatalf("unexpected error: %v", merr)
+	}
+
+	var got Error
+	if uerr := got.UnmarshalProto(buf); uerr != nil {
+		t.Fatalf("unexpected error: %v", uerr)
+	}
+
+	expected := map[string]any{"ch": "(chan int)", "n": float64(1)}
+	if !reflect.DeepEqual(got.fields, expected) {
+		t.Errorf("expected fields %v, got %v", expected, got.fields)
+	}
+}
+
+func TestError_UnmarshalProtoInvalid(t *testing.T) {
+	var e Error
+	if err := e.UnmarshalProto([]byte{0x0a, 0x05, 'a'}); !Is(err, ErrUnmarshalError) {
+		t.Errorf("expected ErrUnmarshalError, got %v", err)
+	}
+}
+
+// TestError_MarshalProtoSchema decodes the output with a descriptor
+// following proto/errors.proto.
+func TestError_MarshalProtoSchema(t *testing.T) {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(num),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			JsonName: proto.String(name),
+		}
+		if repeated {
+			f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	const (
+		str = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		s32 = descriptorpb.FieldDescriptorProto_TYPE_SINT32
+		i32 = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		bl  = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		msg = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("errors.proto"),
+		Package:    proto.String("axkit.errors.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Error"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("message", 1, str, "", false),
+					field("code", 2, str, "", false),
+					field("severity", 3, s32, "", false),
+					field("severity_name", 4, str, "", false),
+					field("status_code", 5, i32, "", false),
+					field("protected", 6, bl, "", false),
+					field("fields", 7, msg, ".google.protobuf.Struct", false),
+					field("stack", 8, msg, ".axkit.errors.v1.StackFrame", true),
+					field("wrapped", 9, msg, ".axkit.errors.v1.Error", false),
+					field("pure_wrapper", 10, bl, "", false),
+					field("formatted", 11, bl, "", false),
+					field("opaque", 12, bl, "", false),
+					field("shared_stack", 13, bl, "", false),
+					field("template", 14, bl, "", false),
+				},
+			},
+			{
+				Name: proto.String("StackFrame"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("function", 1, str, "", false),
+					field("file", 2, str, "", false),
+					field("line", 3, i32, "", false),
+				},
+			},
+		},
+	}
+
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(structpb.File_google_protobuf_struct_proto),
+			fdp,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	desc, err := files.FindDescriptorByName("axkit.errors.v1.Error")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := Template("lookup failed").Code("CRM-0500").Severity(Critical).StatusCode(503).
+		Wrap(io.EOF).
+		Set("customerId", 42)
+
+	buf, err := e.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+	if err := proto.Unmarshal(buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.GetUnknown()) != 0 {
+		t.Errorf("unexpected unknown fields")
+	}
+
+	fd := m.Descriptor().Fields()
+	get := func(name string) protoreflect.Value {
+		return m.Get(fd.ByName(protoreflect.Name(name)))
+	}
+
+	if v := get("code").String(); v != "CRM-0500" {
+		t.Errorf("expected code %q, got %q", "CRM-0500", v)
+	}
+	if v := get("severity").Int(); v != int64(Critical) {
+		t.Errorf("expected severity %d, got %d", Critical, v)
+	}
+	if v := get("severity_name").String(); v != "critical" {
+		t.Errorf("expected severity name %q, got %q", "critical", v)
+	}
+	if v := get("status_code").Int(); v != 503 {
+		t.Errorf("expected status code 503, got %d", v)
+	}
+	if v := get("stack").List().Len(); v != len(e.stack) {
+		t.Errorf("expected %d stack frames, got %d", len(e.stack), v)
+	}
+	wrapped := get("wrapped").Message()
+	if v := wrapped.Get(fd.ByName("message")).String(); v != io.EOF.Error() {
+		t.Errorf("expected wrapped message %q, got %q", io.EOF.Error(), v)
+	}
+	if !wrapped.Get(fd.ByName("opaque")).Bool() {
+		t.Errorf("expected wrapped error to be opaque")
+	}
+}
@@ -0,0 +1,160 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Redactor transforms the value of a sensitive field before it's written
+// to the output. It returns false if the field must be dropped.
+type Redactor func(key string, value any) (any, bool)
+
+var (
+	// RedactMask replaces the value with asterisks. Strings longer than
+	// 8 characters keep the last 4 characters visible.
+	RedactMask Redactor = redactMask
+
+	// RedactHash replaces the value with a truncated SHA-256 hash of its
+	// string representation. Equal values produce equal hashes, which allows
+	// correlating log entries without revealing the value.
+	RedactHash Redactor = redactHash
+
+	// RedactDrop removes the field from the output.
+	RedactDrop Redactor = redactDrop
+
+	// DefaultRedactor is used for sensitive fields marked without a redactor.
+	DefaultRedactor Redactor = RedactMask
+)
+
+var (
+	sensitiveMu sync.RWMutex
+	sensitive   map[string]Redactor
+)
+
+// MarkSensitive marks the field key as sensitive for all errors.
+// The value is passed through r when the error is serialized.
+// If r is nil, DefaultRedactor is used.
+func MarkSensitive(key string, r Redactor) {
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+
+	if sensitive == nil {
+		sensitive = make(map[string]Redactor)
+	}
+	sensitive[key] = r
+}
+
+// Sensitive marks the field key as sensitive for errors created from the template.
+// The value is passed through r when the error is serialized.
+// If r is nil, DefaultRedactor is used.
+func (et *ErrorTemplate) Sensitive(key string, r Redactor) *ErrorTemplate {
+	if et.sensitive == nil {
+		et.sensitive = make(map[string]Redactor)
+	}
+	et.sensitive[key] = r
+	return et
+}
+
+// WithRedactor overrides the redactor used for all sensitive fields.
+// It allows the client and the server output of the same error to differ:
+//
+//	server := errors.ToJSON(err, errors.WithAttributes(errors.ServerOutputFormat))
+//	client := errors.ToJSON(err, errors.WithAttributes(errors.ClientDebugOutputFormat),
+//		errors.WithRedactor(errors.RedactDrop))
+func WithRedactor(r Redactor) Option {
+	return func(e *ErrorFormattingOptions) {
+		e.redactor = r
+	}
+}
+
+// redactorOf returns the redactor for the field key, looking at the templates
+// along the error chain first and at the global registry after.
+func redactorOf(err error, key string) (Redactor, bool) {
+	for l := range chainLevels(err) {
+		if r, ok := l.sensitive[key]; ok {
+			return r, true
+		}
+	}
+
+	sensitiveMu.RLock()
+	r, ok := sensitive[key]
+	sensitiveMu.RUnlock()
+	return r, ok
+}
+
+// hasRedactedFields reports whether any of the fields is redacted
+// according to the formatting options.
+func hasRedactedFields(err error, fields map[string]any, option *ErrorFormattingOptions) bool {
+	if option.include&AddSensitive != 0 {
+		return false
+	}
+	for k := range fields {
+		if _, ok := redactorOf(err, k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactFields returns a copy of fields with sensitive values redacted
+// according to the formatting options.
+func redactFields(err error, fields map[string]any, option *ErrorFormattingOptions) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	res := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if v, ok := redactValue(err, k, v, option); ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// redactValue returns the value of the field redacted according to
+// the formatting options. It returns false if the field must be dropped.
+func redactValue(err error, key string, value any, option *ErrorFormattingOptions) (any, bool) {
+	if option.include&AddSensitive != 0 {
+		return value, true
+	}
+
+	r, ok := redactorOf(err, key)
+	if !ok {
+		return value, true
+	}
+
+	switch {
+	case option.redactor != nil:
+		r = option.redactor
+	case r == nil:
+		r = DefaultRedactor
+	}
+	return r(key, value)
+}
+
+const maskedValue = "***"
+
+func redactMask(_ string, value any) (any, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return maskedValue, true
+	}
+	r := []rune(s)
+	if len(r) <= 8 {
+		return maskedValue, true
+	}
+	return strings.Repeat("*", len(r)-4) + string(r[len(r)-4:]), true
+}
+
+func redactHash(_ string, value any) (any, bool) {
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return "sha256:" + hex.EncodeToString(sum[:8]), true
+}
+
+func redactDrop(string, any) (any, bool) {
+	return nil, false
+}
@@ -0,0 +1,126 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRedactors(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor Redactor
+		value    any
+		expected any
+		keep     bool
+	}{
+		{"mask short string", RedactMask, "secret", maskedValue, true},
+		{"mask long string", RedactMask, "4111111111111111", "************1111", true},
+		{"mask short multibyte string", RedactMask, "пароль12", maskedValue, true},
+		{"mask long multibyte string", RedactMask, "секретный-ключ", "**********ключ", true},
+		{"mask number", RedactMask, 42, maskedValue, true},
+		{"hash", RedactHash, "john@example.com", "sha256:855f96e983f1f8e8", true},
+		{"drop", RedactDrop, "john@example.com", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, keep := tt.redactor("key", tt.value)
+			if keep != tt.keep {
+				t.Errorf("expected keep %v, got %v", tt.keep, keep)
+			}
+			if v != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestTemplate_SensitiveAfterNew(t *testing.T) {
+	tmpl := Template("failure").Sensitive("card", RedactMask).Require(Key[string]("card"))
+	err := tmpl.New().Set("card", "4111111111111111")
+	wrapped := tmpl.Wrap(io.EOF)
+
+	tmpl.Sensitive("email", RedactDrop).Optional(Key[string]("email"))
+
+	for _, e := range []*Error{err, wrapped} {
+		if len(e.sensitive) != 1 || len(e.schema) != 1 {
+			t.Errorf("expected the error to keep the template's declarations, got %v %v", e.sensitive, e.schema)
+		}
+	}
+}
+
+func TestMarkSensitive(t *testing.T) {
+	MarkSensitive("token", nil)
+	t.Cleanup(func() {
+		sensitiveMu.Lock()
+		delete(sensitive, "token")
+		sensitiveMu.Unlock()
+	})
+
+	err := Template("unauthorized").New().Set("token", "abc").Set("user", "john")
+	se := Serialize(err, WithAttributes(AddFields))
+
+	if v := se.Fields["token"]; v != maskedValue {
+		t.Errorf("expected token to be masked, got %v", v)
+	}
+	if v := se.Fields["user"]; v != "john" {
+		t.Errorf("expected user to be kept, got %v", v)
+	}
+	if v := err.fields["token"]; v != "abc" {
+		t.Errorf("expected error fields to stay untouched, got %v", v)
+	}
+}
+
+func TestErrorTemplate_Sensitive(t *testing.T) {
+	ErrPaymentFailed := Template("payment failed").
+		Sensitive("card", RedactMask).
+		Sensitive("email", RedactHash).
+		Sensitive("cvv", RedactDrop)
+
+	inner := ErrPaymentFailed.New().
+		Set("card", "4111111111111111").
+		Set("email", "john@example.com").
+		Set("cvv", "123")
+	err := Template("checkout failed").Wrap(inner)
+
+	t.Run("server output", func(t *testing.T) {
+		var res SerializedError
+		if jerr := json.Unmarshal(ToJSON(err, WithAttributes(ServerOutputFormat)), &res); jerr != nil {
+			t.Fatalf("unexpected error: %v", jerr)
+		}
+
+		if v := res.Fields["card"]; v != "************1111" {
+			t.Errorf("expected masked card, got %v", v)
+		}
+		if v, _ := res.Fields["email"].(string); !strings.HasPrefix(v, "sha256:") {
+			t.Errorf("expected hashed email, got %v", v)
+		}
+		if _, ok := res.Fields["cvv"]; ok {
+			t.Error("expected cvv to be dropped")
+		}
+	})
+
+	t.Run("client output", func(t *testing.T) {
+		se := Serialize(err, WithAttributes(ClientDebugOutputFormat), WithRedactor(RedactDrop))
+		if len(se.Fields) != 0 {
+			t.Errorf("expected all sensitive fields to be dropped, got %v", se.Fields)
+		}
+	})
+
+	t.Run("raw output", func(t *testing.T) {
+		se := Serialize(err, WithAttributes(ServerOutputFormat|AddSensitive))
+		if v := se.Fields["cvv"]; v != "123" {
+			t.Errorf("expected raw cvv, got %v", v)
+		}
+	})
+}
+
+func TestRedact_DefaultRedactor(t *testing.T) {
+	err := Template("failure").Sensitive("password", nil).Wrap(nil).Set("password", "qwerty")
+	se := Serialize(err)
+	if v := se.Fields["password"]; v != maskedValue {
+		t.Errorf("expected password to be masked by the default redactor, got %v", v)
+	}
+}
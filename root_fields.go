@@ -0,0 +1,214 @@
+package errors
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ErrRootLevelCollision is reported to the alarmer when a root-level field
+// can't be promoted because its key collides with another member.
+var ErrRootLevelCollision = Template("root-level field collides with a member of the serialized error").Severity(Tiny)
+
+// RootLevelCollision sets how root-level fields whose keys collide with
+// the members of the serialized error, like "msg" or "code", are written.
+type RootLevelCollision uint8
+
+const (
+	// PrefixCollisions writes the colliding field under its key prefixed
+	// by the fields member key and a dot, like "fields.code".
+	PrefixCollisions RootLevelCollision = iota
+
+	// ReportCollisions leaves the colliding field where it was and
+	// notifies the alarmer with ErrRootLevelCollision.
+	ReportCollisions
+)
+
+// WithRootLevelFields adds fields written as members of the serialized
+// error itself instead of the fields object.
+//
+// A field is looked up in the error and, if it's missing, in the wrapped
+// errors from the outermost to the innermost one. A dotted path like
+// "user.id" refers to a nested map value, unless there is a field named
+// "user.id". The field is written under its path; see WithRootLevelField
+// to rename it. Collisions with other members are handled as set by
+// WithRootLevelCollisions.
+func WithRootLevelFields(fields []string) Option {
+	return func(e *ErrorFormattingOptions) {
+		if e.rootLevelFields == nil {
+			// Later options append to a copy.
+			e.rootLevelFields = slices.Clip(fields)
+			return
+		}
+		e.rootLevelFields = append(e.rootLevelFields, fields...)
+	}
+}
+
+// WithRootLevelField adds a root-level field as WithRootLevelFields does,
+// written under the key instead of its path:
+//
+//	errors.WithRootLevelField("request.id", "requestId")
+func WithRootLevelField(path, key string) Option {
+	return func(e *ErrorFormattingOptions) {
+		e.rootLevelFields = append(e.rootLevelFields, path)
+		e.rootLevelKeys = maps.Clone(e.rootLevelKeys)
+		if e.rootLevelKeys == nil {
+			e.rootLevelKeys = make(map[string]string)
+		}
+		e.rootLevelKeys[path] = key
+	}
+}
+
+// WithRootLevelCollisions sets how root-level fields colliding with other
+// members are written. PrefixCollisions is used by default.
+func WithRootLevelCollisions(c RootLevelCollision) Option {
+	return func(e *ErrorFormattingOptions) {
+		e.rootLevelCollisions = c
+	}
+}
+
+// rootField is a field promoted to the top level of the serialized error.
+type rootField struct {
+	path  string
+	key   string
+	value any
+
+	// outer tells that the field belongs to the outermost error,
+	// so it's moved out of the fields object.
+	outer bool
+}
+
+// appendPromotedFields looks up the root-level fields along the chain
+// of err and appends them to dst. Values are redacted according to
+// the formatting options.
+func appendPromotedFields(dst []rootField, err *Error, option *ErrorFormattingOptions) []rootField {
+	if len(option.rootLevelFields) == 0 {
+		return dst
+	}
+
+	n := len(dst)
+	res := dst
+	for _, path := range option.rootLevelFields {
+		if slices.ContainsFunc(res[n:], func(f rootField) bool { return f.path == path }) {
+			continue
+		}
+
+		key := path
+		if k, ok := option.rootLevelKeys[path]; ok {
+			key = k
+		}
+
+		first := true
+		for l := range chainLevels(err) {
+			if v, exact, ok := lookupField(err, l.fields, path, option); ok {
+				res = append(res, rootField{path: path, key: key, value: v, outer: first && exact})
+				break
+			}
+			first = false
+		}
+	}
+	return res
+}
+
+// lookupField returns the redacted field value at the path. It reports
+// whether the path is a field key rather than a path into a nested map.
+func lookupField(err error, fields map[string]any, path string, option *ErrorFormattingOptions) (any, bool, bool) {
+	if v, ok := fields[path]; ok {
+		v, ok = redactValue(err, path, v, option)
+		return v, true, ok
+	}
+
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		v, ok := fields[path[:i]]
+		if !ok {
+			continue
+		}
+		if v, ok = redactValue(err, path[:i], v, option); !ok {
+			continue
+		}
+		if v, ok = nestedValue(v, path[i+1:]); ok {
+			return v, false, true
+		}
+	}
+	return nil, false, false
+}
+
+// nestedValue returns the value at the dotted path in a map with string keys.
+func nestedValue(v any, path string) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	get := func(key string) (any, bool) {
+		x := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+		if !x.IsValid() {
+			return nil, false
+		}
+		return x.Interface(), true
+	}
+
+	if x, ok := get(path); ok {
+		return x, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if x, ok := get(path[:i]); ok {
+			if x, ok = nestedValue(x, path[i+1:]); ok {
+				return x, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// placeRootFields appends the promoted fields with their final keys to dst.
+// A key that is reserved or taken by a previous field is prefixed;
+// with ReportCollisions, or if the prefixed key collides as well,
+// the field isn't promoted and the alarmer is notified.
+func placeRootFields(dst, root []rootField, reserved func(string) bool, prefix string, c RootLevelCollision) []rootField {
+	if len(root) == 0 {
+		return dst
+	}
+
+	n := len(dst)
+	res := dst
+	taken := func(key string) bool {
+		return reserved(key) || slices.ContainsFunc(res[n:], func(f rootField) bool { return f.key == key })
+	}
+
+	for _, f := range root {
+		if taken(f.key) {
+			if c != PrefixCollisions || taken(prefix+f.key) {
+				if alarmer != nil {
+					alarmer.Alarm(ErrRootLevelCollision.New().Set("field", f.path).Set("key", f.key))
+				}
+				continue
+			}
+			f.key = prefix + f.key
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// isPromoted reports whether the field of the outermost error
+// is moved to the top level.
+func isPromoted(root []rootField, key string) bool {
+	for _, f := range root {
+		if f.outer && f.path == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRootKey reports whether a promoted field is written under the key.
+func hasRootKey(root []rootField, key string) bool {
+	return slices.ContainsFunc(root, func(f rootField) bool { return f.key == key })
+}
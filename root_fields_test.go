@@ -0,0 +1,174 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testRootFields promotes the fields of serr as if they belonged
+// to the outermost error.
+func testRootFields(serr *SerializedError, paths []string) []rootField {
+	var res []rootField
+	for _, path := range paths {
+		if v, ok := serr.Fields[path]; ok {
+			res = append(res, rootField{path: path, key: path, value: v, outer: true})
+		}
+	}
+	return res
+}
+
+func TestWithRootLevelField(t *testing.T) {
+	var option ErrorFormattingOptions
+	WithRootLevelFields([]string{"a"})(&option)
+	WithRootLevelField("b.c", "c")(&option)
+	WithRootLevelFields([]string{"d"})(&option)
+
+	if expected := []string{"a", "b.c", "d"}; !reflect.DeepEqual(option.rootLevelFields, expected) {
+		t.Errorf("expected %v, got %v", expected, option.rootLevelFields)
+	}
+	if expected := map[string]string{"b.c": "c"}; !reflect.DeepEqual(option.rootLevelKeys, expected) {
+		t.Errorf("expected %v, got %v", expected, option.rootLevelKeys)
+	}
+}
+
+func TestNestedValue(t *testing.T) {
+	type labels map[string]string
+
+	tests := []struct {
+		value    any
+		path     string
+		expected any
+		ok       bool
+	}{
+		{map[string]any{"id": 7}, "id", 7, true},
+		{map[string]any{"a": map[string]any{"b": map[string]any{"c": 1}}}, "a.b.c", 1, true},
+		{map[string]any{"a.b": 2, "a": map[string]any{"b": 3}}, "a.b", 2, true},
+		{map[string]any{"a": map[string]any{"b.c": 4}}, "a.b.c", 4, true},
+		{labels{"env": "prod"}, "env", "prod", true},
+		{map[string]any{"id": 7}, "name", nil, false},
+		{map[string]any{"id": 7}, "id.x", nil, false},
+		{map[int]any{1: 1}, "1", nil, false},
+		{"text", "x", nil, false},
+		{nil, "x", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := nestedValue(tt.value, tt.path)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%v %q: expected %v, %v, got %v, %v", tt.value, tt.path, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestToJSON_RootLevelFieldsChain(t *testing.T) {
+	inner := Template("customer not found").Code("CRM-0404").
+		Sensitive("token", RedactMask).
+		New().
+		Set("requestId", "req-1").
+		Set("token", "secret").
+		Set("tenant", map[string]any{"name": "acme"})
+	err := Template("checkout failed").Code("CHK-0001").
+		Wrap(fmt.Errorf("loading customer: %w", inner)).
+		Set("user", map[string]any{"id": 7}).
+		Set("requestId", "req-0").
+		Set("code", "shadowed")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "outermost error first",
+			opts:     []Option{WithRootLevelFields([]string{"requestId"})},
+			expected: `{"msg":"checkout failed","severity":"unknown","code":"CHK-0001","fields":{"code":"shadowed","user":{"id":7}},"requestId":"req-0"}`,
+		},
+		{
+			name: "wrapped errors and nested paths",
+			opts: []Option{
+				WithRootLevelFields([]string{"user.id", "token", "missing"}),
+				WithRootLevelField("tenant.name", "tenant"),
+			},
+			expected: `{"msg":"checkout failed","severity":"unknown","code":"CHK-0001","fields":{"code":"shadowed","requestId":"req-0","user":{"id":7}},"user.id":7,"token":"***","tenant":"acme"}`,
+		},
+		{
+			name:     "prefixed collisions",
+			opts:     []Option{WithRootLevelFields([]string{"code", "requestId"}), WithRootLevelField("user.id", "requestId")},
+			expected: `{"msg":"checkout failed","severity":"unknown","code":"CHK-0001","fields":{"user":{"id":7}},"fields.code":"shadowed","requestId":"req-0","fields.requestId":7}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ToJSON(err, tt.opts...)); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToJSON_ReportCollisions(t *testing.T) {
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	err := Template("failure").Code("X-1").New().
+		Set("code", "shadowed").
+		Set("requestId", "req-1")
+
+	expected := `{"msg":"failure","severity":"unknown","code":"X-1","fields":{"code":"shadowed"},"requestId":"req-1"}`
+	got := string(ToJSON(err,
+		WithRootLevelFields([]string{"code", "requestId"}),
+		WithRootLevelCollisions(ReportCollisions)))
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if !mock.called || !Is(mock.err, ErrRootLevelCollision) {
+		t.Fatalf("expected ErrRootLevelCollision alarm, got %v", mock.err)
+	}
+	if field, _ := Field(mock.err, "field"); field != "code" {
+		t.Errorf("expected colliding field code, got %v", field)
+	}
+}
+
+func TestToLogfmt_RootLevelCollisions(t *testing.T) {
+	err := Template("failure").Code("X-1").New().
+		Set("code", "shadowed").
+		Set("user", map[string]any{"id": 7})
+
+	expected := `msg=failure severity=unknown code=X-1 fields.code=shadowed user.id=7 fields.user.id=7`
+	got := string(ToLogfmt(err, WithRootLevelFields([]string{"code", "user.id"})))
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestProblemFormatter_RootLevelCollisions(t *testing.T) {
+	err := Template("failure").Code("X-1").New().
+		Set("title", "shadowed").
+		Set("requestId", "req-1")
+
+	f, _ := LookupFormatter(FormatProblem)
+
+	var got map[string]any
+	if jerr := json.Unmarshal(f.Format(err, WithRootLevelFields([]string{"title", "requestId"})), &got); jerr != nil {
+		t.Fatalf("unexpected error: %v", jerr)
+	}
+
+	expected := map[string]any{
+		"type":         "about:blank",
+		"title":        "Internal Server Error",
+		"status":       float64(500),
+		"detail":       "failure",
+		"code":         "X-1",
+		"severity":     "unknown",
+		"fields.title": "shadowed",
+		"requestId":    "req-1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
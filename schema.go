@@ -0,0 +1,191 @@
+package errors
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// fieldSpec describes a field declared on an ErrorTemplate.
+type fieldSpec struct {
+	key      FieldKey
+	required bool
+}
+
+// Require declares a field that every error created from the template
+// must carry. The value must be of the key's type; use Key[any] to
+// accept any type.
+//
+//	var (
+//		FieldName   = errors.Key[string]("field")
+//		FieldReason = errors.Key[string]("reason")
+//
+//		ErrInvalidInput = errors.Template("invalid input").Code("CRM-0901").
+//			Require(FieldName, FieldReason)
+//	)
+//
+// Declarations are checked by ValidateFields.
+func (et *ErrorTemplate) Require(keys ...FieldKey) *ErrorTemplate {
+	return et.declare(true, keys)
+}
+
+// Optional declares a field that errors created from the template may carry.
+// If present, the value must be of the key's type.
+func (et *ErrorTemplate) Optional(keys ...FieldKey) *ErrorTemplate {
+	return et.declare(false, keys)
+}
+
+func (et *ErrorTemplate) declare(required bool, keys []FieldKey) *ErrorTemplate {
+	for _, key := range keys {
+		et.schema = slices.DeleteFunc(et.schema, func(fs fieldSpec) bool {
+			return fs.key.String() == key.String()
+		})
+		et.schema = append(et.schema, fieldSpec{key: key, required: required})
+	}
+	return et
+}
+
+// ErrFieldSchemaViolation is returned by ValidateFields.
+var ErrFieldSchemaViolation = Template("error fields violate the schema").Severity(Tiny)
+
+var (
+	allowedFieldsMu sync.RWMutex
+	allowedFields   = map[string]struct{}{}
+)
+
+// AllowFields declares fields that may be carried by any error without
+// being declared on templates, like request or trace identifiers.
+// Fields registered with RegisterContextExtractor are allowed automatically.
+func AllowFields(keys ...string) {
+	allowedFieldsMu.Lock()
+	defer allowedFieldsMu.Unlock()
+
+	for _, k := range keys {
+		allowedFields[k] = struct{}{}
+	}
+}
+
+func fieldAllowed(key string) bool {
+	allowedFieldsMu.RLock()
+	_, ok := allowedFields[key]
+	allowedFieldsMu.RUnlock()
+	if ok {
+		return true
+	}
+
+	contextExtractorsMu.RLock()
+	defer contextExtractorsMu.RUnlock()
+	return slices.ContainsFunc(contextExtractors, func(ce contextExtractor) bool {
+		return ce.field == key
+	})
+}
+
+// ValidateFields checks the fields of the error chain against the fields
+// declared by templates with Require and Optional. It returns an error
+// wrapping ErrFieldSchemaViolation if a required field is missing, a field
+// has a value of the wrong type, or a field is declared by none of the
+// templates in the chain. Chains without declarations are not checked.
+//
+// The names of offending fields are listed in the "missing", "mistyped"
+// and "undeclared" fields of the returned error.
+func ValidateFields(err error) error {
+	declared := make(map[string]fieldSpec)
+	for l := range chainLevels(err) {
+		for _, fs := range l.schema {
+			if prev, ok := declared[fs.key.String()]; !ok || (fs.required && !prev.required) {
+				declared[fs.key.String()] = fs
+			}
+		}
+	}
+
+	if len(declared) == 0 {
+		return nil
+	}
+
+	fields := Fields(err)
+
+	var missing, mistyped, undeclared []string
+	var problems []string
+
+	for _, name := range slices.Sorted(maps.Keys(declared)) {
+		fs := declared[name]
+		v, ok := fields[name]
+		switch {
+		case !ok && fs.required:
+			missing = append(missing, name)
+			problems = append(problems, "missing required field "+strconv.Quote(name))
+		case ok:
+			if _, err := fs.key.convert(v); err != nil {
+				mistyped = append(mistyped, name)
+				problems = append(problems, "field "+strconv.Quote(name)+" is not "+fs.key.typeName())
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if _, ok := declared[name]; !ok && !fieldAllowed(name) {
+			undeclared = append(undeclared, name)
+			problems = append(problems, "undeclared field "+strconv.Quote(name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	res := ErrFieldSchemaViolation.Wrap(New(strings.Join(problems, "; ")))
+	if len(missing) > 0 {
+		res.Set("missing", missing)
+	}
+	if len(mistyped) > 0 {
+		res.Set("mistyped", mistyped)
+	}
+	if len(undeclared) > 0 {
+		res.Set("undeclared", undeclared)
+	}
+	return res
+}
+
+// fieldChecker holds checkFields while field checks are enabled.
+// It is not referenced by notify directly to avoid an initialization cycle
+// through ErrFieldSchemaViolation.
+var fieldChecker atomic.Pointer[func(error)]
+
+// SetFieldChecks enables or disables validation of fields on serialization.
+// When enabled, every error passed to Serialize or ToJSON is checked by
+// ValidateFields and violations are passed to FieldViolationHandler.
+//
+// Field checks are enabled by default in binaries built with
+// the "errors_debug" build tag.
+func SetFieldChecks(enabled bool) {
+	if !enabled {
+		fieldChecker.Store(nil)
+		return
+	}
+	check := checkFields
+	fieldChecker.Store(&check)
+}
+
+// FieldViolationHandler receives errors returned by ValidateFields when
+// field checks are enabled, as well as values rejected by With.
+// By default it passes them to Alarmer, if set.
+var FieldViolationHandler = func(violation error) {
+	if alarmer != nil {
+		alarmer.Alarm(violation)
+	}
+}
+
+func checkFields(err error) {
+	if verr := ValidateFields(err); verr != nil {
+		reportFieldViolation(verr)
+	}
+}
+
+func reportFieldViolation(violation error) {
+	if FieldViolationHandler != nil {
+		FieldViolationHandler(violation)
+	}
+}
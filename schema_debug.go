@@ -0,0 +1,7 @@
+//go:build errors_debug
+
+package errors
+
+func init() {
+	SetFieldChecks(true)
+}
@@ -0,0 +1,164 @@
+package errors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestValidateFields(t *testing.T) {
+	field := Key[string]("field")
+	reason := Key[string]("reason")
+	limit := Key[int]("limit")
+
+	errInvalidInput := Template("invalid input").Code("CRM-0901").
+		Require(field, reason).
+		Optional(limit)
+
+	tests := []struct {
+		name       string
+		err        error
+		valid      bool
+		missing    []string
+		mistyped   []string
+		undeclared []string
+	}{
+		{
+			name:  "all required fields",
+			err:   errInvalidInput.New().With(field, "email").With(reason, "empty"),
+			valid: true,
+		},
+		{
+			name:  "optional field",
+			err:   errInvalidInput.New().With(field, "email").With(reason, "empty").With(limit, 5),
+			valid: true,
+		},
+		{
+			name:    "missing required field",
+			err:     errInvalidInput.New().With(field, "email"),
+			missing: []string{"reason"},
+		},
+		{
+			name:       "undeclared field",
+			err:        errInvalidInput.New().With(field, "email").With(reason, "empty").Set("user", "john"),
+			undeclared: []string{"user"},
+		},
+		{
+			name:     "wrong type",
+			err:      errInvalidInput.New().With(field, "email").With(reason, "empty").Set("limit", "five"),
+			mistyped: []string{"limit"},
+		},
+		{
+			name:  "required field set on wrapped error",
+			err:   Template("request failed").Wrap(errInvalidInput.New().With(field, "email").With(reason, "empty")),
+			valid: true,
+		},
+		{
+			name:  "no schema",
+			err:   Template("no schema").New().Set("anything", 1),
+			valid: true,
+		},
+		{
+			name:  "nil error",
+			err:   nil,
+			valid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := ValidateFields(tt.err)
+			if tt.valid {
+				if verr != nil {
+					t.Errorf("expected no violation, got %v", verr)
+				}
+				return
+			}
+
+			if !Is(verr, ErrFieldSchemaViolation) {
+				t.Fatalf("expected ErrFieldSchemaViolation, got %v", verr)
+			}
+
+			for name, expected := range map[string][]string{
+				"missing":    tt.missing,
+				"mistyped":   tt.mistyped,
+				"undeclared": tt.undeclared,
+			} {
+				got, _ := Field(verr, name)
+				if expected == nil {
+					if got != nil {
+						t.Errorf("expected no %s fields, got %v", name, got)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected %s fields %v, got %v", name, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorTemplate_Require(t *testing.T) {
+	reason := Key[string]("reason")
+
+	et := Template("invalid input").Optional(reason).Require(reason)
+	if len(et.schema) != 1 || !et.schema[0].required {
+		t.Errorf("expected a single required declaration, got %+v", et.schema)
+	}
+
+	if verr := ValidateFields(et.New()); verr == nil {
+		t.Errorf("expected missing required field to be reported")
+	}
+}
+
+func TestAllowFields(t *testing.T) {
+	errInvalidInput := Template("invalid input").Require(Key[string]("reason"))
+
+	registerTestExtractors(t)
+	AllowFields("schemaTestHost")
+	t.Cleanup(func() {
+		allowedFieldsMu.Lock()
+		delete(allowedFields, "schemaTestHost")
+		allowedFieldsMu.Unlock()
+	})
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	err := errInvalidInput.NewCtx(ctx).
+		Set("reason", "empty").
+		Set("schemaTestHost", "db-1")
+
+	if verr := ValidateFields(err); verr != nil {
+		t.Errorf("expected no violation, got %v", verr)
+	}
+}
+
+func TestSetFieldChecks(t *testing.T) {
+	var violations []error
+
+	prev := FieldViolationHandler
+	FieldViolationHandler = func(verr error) { violations = append(violations, verr) }
+	SetFieldChecks(true)
+	t.Cleanup(func() {
+		FieldViolationHandler = prev
+		SetFieldChecks(false)
+	})
+
+	errInvalidInput := Template("invalid input").Require(Key[string]("reason"))
+
+	_ = ToJSON(errInvalidInput.New().Set("reason", "empty"))
+	if len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+
+	_ = ToJSON(errInvalidInput.New())
+	if len(violations) != 1 || !Is(violations[0], ErrFieldSchemaViolation) {
+		t.Errorf("expected one violation, got %v", violations)
+	}
+
+	SetFieldChecks(false)
+	_ = ToJSON(errInvalidInput.New())
+	if len(violations) != 1 {
+		t.Errorf("expected checks to be disabled, got %d violations", len(violations))
+	}
+}
@@ -1,8 +1,19 @@
 package errors
 
-import "bytes"
+import (
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // SeverityLevel describes error severity levels.
+//
+// Levels are ordered by their numeric value: the greater the value,
+// the more severe the error. Besides the predefined levels, custom levels
+// can be added with RegisterSeverity.
 type SeverityLevel int
 
 const (
@@ -38,43 +49,255 @@ const (
 	sunknown  = "unknown"
 )
 
+// SeverityInfo describes the name and the default behaviour of a severity level.
+type SeverityInfo struct {
+	// Name is used for string representation and JSON marshalling.
+	Name string
+
+	// CaptureStack enables capturing of a call stack by ErrorTemplate.New,
+	// and by the wrapping functions if the wrapped error has no call stack,
+	// for errors of the level.
+	CaptureStack bool
+
+	// Alarm enables calling Alarmer automatically when an error
+	// of the level, as chosen by EffectiveSeverity, is created.
+	// Wrapping the error doesn't call it again.
+	Alarm bool
+
+	// LogLevel is the recommended log level for errors of the level.
+	LogLevel slog.Level
+}
+
+type severityRegistry struct {
+	levels map[SeverityLevel]SeverityInfo
+	names  map[string]SeverityLevel
+	quoted map[SeverityLevel][]byte
+}
+
+var (
+	severitiesMu sync.Mutex
+	severities   atomic.Pointer[severityRegistry]
+)
+
+func init() {
+	severities.Store(&severityRegistry{
+		levels: map[SeverityLevel]SeverityInfo{
+			Unknown:  {Name: sunknown, CaptureStack: true, LogLevel: slog.LevelError},
+			Tiny:     {Name: stiny, CaptureStack: true, LogLevel: slog.LevelWarn},
+			Medium:   {Name: smedium, CaptureStack: true, LogLevel: slog.LevelError},
+			Critical: {Name: scritical, CaptureStack: true, LogLevel: slog.LevelError + 4},
+		},
+		names: map[string]SeverityLevel{
+			sunknown:  Unknown,
+			stiny:     Tiny,
+			smedium:   Medium,
+			scritical: Critical,
+		},
+		quoted: map[SeverityLevel][]byte{
+			Unknown:  unknown,
+			Tiny:     tiny,
+			Medium:   medium,
+			Critical: critical,
+		},
+	})
+}
+
+// RegisterSeverity adds a custom severity level or redefines an existing one.
+// It's intended to be called during program initialization:
+//
+//	const (
+//		Info    errors.SeverityLevel = 10
+//		Warning errors.SeverityLevel = 20
+//		Fatal   errors.SeverityLevel = 50
+//	)
+//
+//	func init() {
+//		errors.RegisterSeverity(Info, errors.SeverityInfo{Name: "info", LogLevel: slog.LevelInfo})
+//		errors.RegisterSeverity(Fatal, errors.SeverityInfo{Name: "fatal", CaptureStack: true, Alarm: true})
+//	}
+//
+// It panics if the name is empty or already used by another level.
+func RegisterSeverity(level SeverityLevel, info SeverityInfo) {
+	if info.Name == "" {
+		panic("axkit/errors: severity name cannot be empty")
+	}
+
+	severitiesMu.Lock()
+	defer severitiesMu.Unlock()
+
+	old := severities.Load()
+	if l, ok := old.names[info.Name]; ok && l != level {
+		panic("axkit/errors: severity name " + strconv.Quote(info.Name) + " is already registered")
+	}
+
+	next := severityRegistry{
+		levels: make(map[SeverityLevel]SeverityInfo, len(old.levels)+1),
+		names:  make(map[string]SeverityLevel, len(old.names)+1),
+		quoted: make(map[SeverityLevel][]byte, len(old.quoted)+1),
+	}
+	for l, si := range old.levels {
+		if l != level {
+			next.levels[l] = si
+			next.names[si.Name] = l
+			next.quoted[l] = old.quoted[l]
+		}
+	}
+	next.levels[level] = info
+	next.names[info.Name] = level
+	next.quoted[level] = []byte(strconv.Quote(info.Name))
+
+	severities.Store(&next)
+}
+
+// Severities returns all registered severity levels in ascending order.
+func Severities() []SeverityLevel {
+	reg := severities.Load()
+	res := make([]SeverityLevel, 0, len(reg.levels))
+	for l := range reg.levels {
+		res = append(res, l)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Info returns the description of the severity level. Unregistered levels
+// get the description of Unknown.
+func (sl SeverityLevel) Info() SeverityInfo {
+	reg := severities.Load()
+	if si, ok := reg.levels[sl]; ok {
+		return si
+	}
+	return reg.levels[Unknown]
+}
+
+// LogLevel returns the recommended log level for errors of the severity level.
+func (sl SeverityLevel) LogLevel() slog.Level {
+	return sl.Info().LogLevel
+}
+
 // String returns severity level string representation.
 func (sl SeverityLevel) String() string {
-	switch sl {
-	case Tiny:
-		return stiny
-	case Medium:
-		return smedium
-	case Critical:
-		return scritical
-	}
-	return sunknown
+	return sl.Info().Name
 }
 
 // MarshalJSON implements json/Marshaller interface.
 func (sl SeverityLevel) MarshalJSON() ([]byte, error) {
-	switch sl {
-	case Tiny:
-		return tiny, nil
-	case Medium:
-		return medium, nil
-	case Critical:
-		return critical, nil
+	reg := severities.Load()
+	if b, ok := reg.quoted[sl]; ok {
+		return b, nil
 	}
-	return unknown, nil
+	return reg.quoted[Unknown], nil
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (sl SeverityLevel) MarshalText() ([]byte, error) {
+	return []byte(sl.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+// The text is parsed by ParseSeverity. Unrecognised text sets
+// the level to Unknown; decode into StrictSeverity to get an error instead.
+func (sl *SeverityLevel) UnmarshalText(text []byte) error {
+	return sl.unmarshalText(text, false)
 }
 
 // UnmarshalJSON implements json/Unmarshaller interface.
+// Both the name of the level as a JSON string and its numeric value
+// as a JSON number are accepted. Unrecognised values set the level
+// to Unknown; decode into StrictSeverity to get an error instead.
 func (sl *SeverityLevel) UnmarshalJSON(data []byte) error {
-	switch {
-	case bytes.Equal(data, tiny):
-		*sl = Tiny
-	case bytes.Equal(data, medium):
-		*sl = Medium
-	case bytes.Equal(data, critical):
-		*sl = Critical
-	default:
-		*sl = 0
+	return sl.unmarshalJSON(data, false)
+}
+
+func (sl *SeverityLevel) unmarshalText(text []byte, strict bool) error {
+	level, err := ParseSeverity(string(text))
+	if err != nil && strict {
+		return err
 	}
+	*sl = level
 	return nil
 }
+
+func (sl *SeverityLevel) unmarshalJSON(data []byte, strict bool) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if s, err := strconv.Unquote(string(data)); err == nil {
+		return sl.unmarshalText([]byte(s), strict)
+	}
+
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		if strict {
+			return invalidSeverity(string(data))
+		}
+		*sl = Unknown
+		return nil
+	}
+
+	if _, ok := severities.Load().levels[SeverityLevel(n)]; !ok && strict {
+		return invalidSeverity(string(data))
+	}
+	*sl = SeverityLevel(n)
+	return nil
+}
+
+// StrictSeverity is a SeverityLevel that is decoded strictly:
+// UnmarshalJSON and UnmarshalText return ErrInvalidSeverity
+// for unrecognised values instead of setting Unknown.
+//
+//	var cfg struct {
+//		AlarmLevel errors.StrictSeverity `json:"alarmLevel"`
+//	}
+//	if err := json.Unmarshal(data, &cfg); err != nil {
+//		return err
+//	}
+//	level := cfg.AlarmLevel.SeverityLevel
+type StrictSeverity struct {
+	SeverityLevel
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (ss *StrictSeverity) UnmarshalText(text []byte) error {
+	return ss.unmarshalText(text, true)
+}
+
+// UnmarshalJSON implements json/Unmarshaller interface.
+func (ss *StrictSeverity) UnmarshalJSON(data []byte) error {
+	return ss.unmarshalJSON(data, true)
+}
+
+// ErrInvalidSeverity is returned when a severity level cannot be parsed.
+var ErrInvalidSeverity = Template("invalid severity level").Severity(Tiny)
+
+// ParseSeverity returns the severity level by its name or numeric value.
+// Names are matched case-insensitively and surrounding spaces are ignored.
+// Only registered levels are accepted; an error wrapping
+// ErrInvalidSeverity is returned otherwise.
+func ParseSeverity(s string) (SeverityLevel, error) {
+	name := strings.TrimSpace(s)
+	reg := severities.Load()
+
+	if level, ok := reg.names[name]; ok {
+		return level, nil
+	}
+
+	for n, level := range reg.names {
+		if strings.EqualFold(n, name) {
+			return level, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(name); err == nil {
+		if _, ok := reg.levels[SeverityLevel(n)]; ok {
+			return SeverityLevel(n), nil
+		}
+	}
+
+	return Unknown, invalidSeverity(s)
+}
+
+func invalidSeverity(s string) error {
+	return ErrInvalidSeverity.Wrap(New(strconv.Quote(s))).Set("value", s)
+}
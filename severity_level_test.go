@@ -1,7 +1,11 @@
 package errors
 
 import (
+	"encoding"
 	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
 	"testing"
 )
 
@@ -68,3 +72,302 @@ func TestSeverityLevel_UnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func restoreSeverities(t *testing.T) {
+	t.Helper()
+	old := severities.Load()
+	t.Cleanup(func() { severities.Store(old) })
+}
+
+func TestRegisterSeverity(t *testing.T) {
+	restoreSeverities(t)
+
+	const (
+		Info    SeverityLevel = 10
+		Warning SeverityLevel = 20
+		Fatal   SeverityLevel = 50
+	)
+
+	RegisterSeverity(Info, SeverityInfo{Name: "info", LogLevel: slog.LevelInfo})
+	RegisterSeverity(Warning, SeverityInfo{Name: "warning", CaptureStack: true, LogLevel: slog.LevelWarn})
+	RegisterSeverity(Fatal, SeverityInfo{Name: "fatal", CaptureStack: true, Alarm: true, LogLevel: slog.LevelError + 8})
+
+	if s := Warning.String(); s != "warning" {
+		t.Errorf("expected %q, got %q", "warning", s)
+	}
+
+	if b, _ := json.Marshal(Fatal); string(b) != `"fatal"` {
+		t.Errorf("expected %q, got %q", `"fatal"`, b)
+	}
+
+	var level SeverityLevel
+	if err := json.Unmarshal([]byte(`"info"`), &level); err != nil || level != Info {
+		t.Errorf("expected %v, got %v (err: %v)", Info, level, err)
+	}
+
+	if l := Fatal.LogLevel(); l != slog.LevelError+8 {
+		t.Errorf("expected log level %v, got %v", slog.LevelError+8, l)
+	}
+
+	expected := []SeverityLevel{Unknown, Tiny, Medium, Critical, Info, Warning, Fatal}
+	if got := Severities(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	t.Run("redefine", func(t *testing.T) {
+		RegisterSeverity(Warning, SeverityInfo{Name: "warn"})
+		if s := Warning.String(); s != "warn" {
+			t.Errorf("expected %q, got %q", "warn", s)
+		}
+		var level SeverityLevel
+		_ = json.Unmarshal([]byte(`"warning"`), &level)
+		if level != Unknown {
+			t.Errorf("expected previous name to be unregistered, got %v", level)
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		RegisterSeverity(42, SeverityInfo{Name: "fatal"})
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		RegisterSeverity(42, SeverityInfo{})
+	})
+}
+
+func TestSeverityInfo_CaptureStack(t *testing.T) {
+	restoreSeverities(t)
+
+	const Info SeverityLevel = 10
+	RegisterSeverity(Info, SeverityInfo{Name: "info"})
+
+	if err := Template("validation failed").Severity(Info).New(); err.stack != nil {
+		t.Errorf("expected no stack trace, got %v", err.stack)
+	}
+	if err := Template("failure").Severity(Medium).New(); err.stack == nil {
+		t.Error("expected stack trace to be populated")
+	}
+
+	tmpl := Template("validation failed").Severity(Info)
+	for name, err := range map[string]*Error{
+		"ErrorTemplate.Wrap": tmpl.Wrap(io.EOF),
+		"Error.Wrap":         tmpl.New().Wrap(io.EOF),
+		"Wrap":               Wrap(tmpl, "validation failed"),
+		"Wrapf":              Wrapf(tmpl, "validation %s", "failed"),
+		"Errorf":             Errorf("validation: %w", tmpl),
+	} {
+		if err.stack != nil {
+			t.Errorf("%s: expected no stack trace, got %v", name, err.stack)
+		}
+	}
+
+	if err := Template("validation failed").Severity(Info).Wrap(Template("failure").New()); err.stack == nil {
+		t.Error("expected stack trace of the wrapped error to be kept")
+	}
+}
+
+func TestSeverityInfo_Alarm(t *testing.T) {
+	restoreSeverities(t)
+
+	mock := &MockAlarmer{}
+	SetAlarmer(mock)
+	t.Cleanup(func() { SetAlarmer(nil) })
+
+	const Fatal SeverityLevel = 50
+	RegisterSeverity(Fatal, SeverityInfo{Name: "fatal", Alarm: true})
+
+	_ = Template("failure").Severity(Critical).New()
+	if mock.called {
+		t.Error("expected no alarm for critical error")
+	}
+
+	err := Template("database is gone").Severity(Fatal).New()
+	if !mock.called || mock.err != err {
+		t.Errorf("expected alarm for fatal error, got %v", mock.err)
+	}
+
+	// Wrapping the error doesn't raise the alarm again.
+	mock.called, mock.err = false, nil
+	_ = Template("query failed").Severity(Tiny).Wrap(err)
+	_ = Wrap(err, "query failed")
+	_ = Wrapf(err, "query %d failed", 1)
+	_ = Errorf("query failed: %w", err)
+	if mock.called {
+		t.Errorf("expected no alarm for wrapped error, got %v", mock.err)
+	}
+
+	// Neither does wrapping it into errors of the level.
+	ErrFatal := Template("database is gone").Severity(Fatal)
+	alarms := &countingAlarmer{}
+	SetAlarmer(alarms)
+	x := ErrFatal.New()
+	y := Template("query failed").Severity(Fatal).Wrap(x)
+	_ = y.Wrap(io.EOF)
+	_ = Wrap(y, "request failed")
+	if alarms.n != 1 {
+		t.Errorf("expected 1 alarm, got %d", alarms.n)
+	}
+	SetAlarmer(mock)
+
+	// Every wrapping function creating an error of the level raises it.
+	for name, create := range map[string]func() *Error{
+		"ErrorTemplate.Wrap": func() *Error { return ErrFatal.Wrap(io.EOF) },
+		"Error.Wrap":         func() *Error { return Template("x").New().Severity(Fatal).Wrap(io.EOF) },
+		"Wrap":               func() *Error { return Wrap(ErrFatal, "query failed") },
+		"Errorf":             func() *Error { return Errorf("query failed: %w", ErrFatal) },
+	} {
+		mock.called, mock.err = false, nil
+		if err := create(); !mock.called || mock.err != err {
+			t.Errorf("%s: expected alarm for fatal error, got %v", name, mock.err)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SeverityLevel
+		valid    bool
+	}{
+		{"tiny", Tiny, true},
+		{"Medium", Medium, true},
+		{" CRITICAL ", Critical, true},
+		{"unknown", Unknown, true},
+		{"3", Critical, true},
+		{"42", Unknown, false},
+		{"fatal", Unknown, false},
+		{"", Unknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := ParseSeverity(tt.input)
+			if level != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, level)
+			}
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got error %v", tt.valid, err)
+			}
+			if err != nil && !Is(err, ErrInvalidSeverity) {
+				t.Errorf("expected ErrInvalidSeverity, got %v", err)
+			}
+		})
+	}
+
+	_, err := ParseSeverity("fatal")
+	if expected := `invalid severity level: "fatal"`; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestSeverityLevel_MarshalText(t *testing.T) {
+	var _ encoding.TextMarshaler = Tiny
+	var _ encoding.TextUnmarshaler = (*SeverityLevel)(nil)
+
+	b, err := Critical.MarshalText()
+	if err != nil || string(b) != scritical {
+		t.Errorf("expected %q, got %q (err: %v)", scritical, b, err)
+	}
+
+	var level SeverityLevel
+	if err := level.UnmarshalText([]byte("Medium")); err != nil || level != Medium {
+		t.Errorf("expected %v, got %v (err: %v)", Medium, level, err)
+	}
+}
+
+func TestSeverityLevel_UnmarshalJSONFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected SeverityLevel
+		strict   bool
+		valid    bool
+	}{
+		{"name", `"critical"`, Critical, false, true},
+		{"case insensitive name", `"TINY"`, Tiny, true, true},
+		{"number", `2`, Medium, true, true},
+		{"unregistered number", `42`, SeverityLevel(42), false, true},
+		{"unregistered number strict", `42`, Unknown, true, false},
+		{"unknown name", `"fatal"`, Unknown, false, true},
+		{"unknown name strict", `"fatal"`, Unknown, true, false},
+		{"boolean", `true`, Unknown, false, true},
+		{"boolean strict", `true`, Unknown, true, false},
+		{"null", `null`, Unknown, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				level SeverityLevel
+				err   error
+			)
+			if tt.strict {
+				var ss StrictSeverity
+				err = json.Unmarshal([]byte(tt.input), &ss)
+				level = ss.SeverityLevel
+			} else {
+				err = json.Unmarshal([]byte(tt.input), &level)
+			}
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got error %v", tt.valid, err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestStrictSeverity(t *testing.T) {
+	type config struct {
+		AlarmLevel StrictSeverity         `json:"alarmLevel"`
+		Limits     map[StrictSeverity]int `json:"limits"`
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"alarmLevel":"critical","limits":{"tiny":1}}`), &c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.AlarmLevel.SeverityLevel != Critical || c.Limits[StrictSeverity{Tiny}] != 1 {
+		t.Errorf("unexpected config %+v", c)
+	}
+
+	err := json.Unmarshal([]byte(`{"limits":{"fatal":1}}`), &c)
+	if !Is(err, ErrInvalidSeverity) {
+		t.Errorf("expected ErrInvalidSeverity, got %v", err)
+	}
+
+	b, _ := json.Marshal(c.AlarmLevel)
+	if string(b) != `"critical"` {
+		t.Errorf("expected %q, got %s", "critical", b)
+	}
+}
+
+func TestSeverityLevel_TextInStructs(t *testing.T) {
+	type config struct {
+		Levels map[SeverityLevel]int `json:"levels"`
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"levels":{"tiny":1,"critical":3}}`), &c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Levels[Tiny] != 1 || c.Levels[Critical] != 3 {
+		t.Errorf("unexpected levels: %v", c.Levels)
+	}
+
+	b, _ := json.Marshal(c)
+	if expected := `{"levels":{"critical":3,"tiny":1}}`; string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// LogValue implements slog.LogValuer. The error is logged as a group
+// holding the error message, severity, code, status code and the fields
+// with sensitive values redacted.
+func (e *Error) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 5)
+	attrs = append(attrs, slog.String("msg", e.Error()))
+
+	if severity := EffectiveSeverity(e); severity != Unknown {
+		attrs = append(attrs, slog.String("severity", severity.String()))
+	}
+	if e.code != "" {
+		attrs = append(attrs, slog.String("code", e.code))
+	}
+	if statusCode := EffectiveStatusCode(e); statusCode != 0 {
+		attrs = append(attrs, slog.Int("statusCode", statusCode))
+	}
+
+	fields := redactFields(e, e.fields, &ErrorFormattingOptions{})
+	if len(fields) > 0 {
+		fa := make([]any, 0, len(fields))
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			fa = append(fa, slog.Any(k, fields[k]))
+		}
+		attrs = append(attrs, slog.Group("fields", fa...))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,42 @@
+package errors
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestError_LogValue(t *testing.T) {
+	err := Template("payment failed").
+		Code("PAY-0001").
+		StatusCode(402).
+		Severity(Medium).
+		Sensitive("card", RedactMask).
+		New().
+		Set("card", "4111111111111111").
+		Set("amount", 10)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Error("request failed", "err", err)
+
+	expected := `level=ERROR msg="request failed" err.msg="payment failed" err.severity=medium err.code=PAY-0001 err.statusCode=402 err.fields.amount=10 err.fields.card=************1111` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestError_LogValueMinimal(t *testing.T) {
+	v := Wrap(nil, "failure").LogValue()
+	attrs := v.Group()
+	if len(attrs) != 1 || attrs[0].Key != "msg" || attrs[0].Value.String() != "failure" {
+		t.Errorf("expected only msg attribute, got %v", attrs)
+	}
+}